@@ -0,0 +1,444 @@
+// Package design codon optimizes proteins for a host and fixes the problems of
+// the optimized CDSs, like restriction sites, repeats and host homology,
+// without changing their protein. The friendzymes_toolkit command runs it for
+// every enzyme and codon table strategy, and it can be used the same way from
+// other Go programs.
+package design
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/goldengate"
+	"github.com/Open-Science-Global/poly/synthesis"
+	"github.com/Open-Science-Global/poly/transform/codon"
+)
+
+// Defaults of Options, the values our parts are designed with.
+const (
+	DefaultRepeatLength  = 10
+	DefaultHostKmer      = 20
+	DefaultHairpinStem   = 20
+	DefaultHairpinWindow = 200
+	DefaultHairpinLoop   = 3
+)
+
+// Options sets what FixSequence removes from a CDS. The zero value removes the
+// forbidden sequences of ForbiddenSequences, repeats and hairpins with the
+// default lengths, and checks no host genome.
+type Options struct {
+	// Forbidden are the sequences to remove, ForbiddenSequences when nil.
+	Forbidden []string
+	// RepeatLength is the shortest repeat to remove inside the CDS.
+	RepeatLength int
+	// HostKmers are the kmers of the host genome, made by KmerTable, that the
+	// CDS must not share. HostKmer is their length.
+	HostKmers map[string]bool
+	HostKmer  int
+	// HairpinStem and HairpinWindow are the hairpins to remove: stems of
+	// HairpinStem bases pairing within HairpinWindow bases, around a loop of
+	// at least DefaultHairpinLoop bases, see FindHairpins.
+	HairpinStem   int
+	HairpinWindow int
+	// LockedRegions are the parts of the CDS that must keep their codons.
+	LockedRegions []LockedRegion
+	// AntiSD is the 3' end of the host 16S rRNA. When set, Shine-Dalgarno
+	// like sequences before start codons inside the CDS, pairing with it
+	// under InternalSDEnergy kcal/mol, are removed, see InternalStarts.
+	AntiSD           string
+	InternalSDEnergy float64
+	// AlternativeORFCodons, when set, shortens the alternative ORFs of the
+	// CDS of at least that many codons, see AlternativeORFs.
+	AlternativeORFCodons int
+	// StartFold, when set, unfolds the 5' end of the mRNA once the problems
+	// are fixed.
+	StartFold *StartFold
+	// Ramp, when set, picks the first codons of Design with a 5' codon ramp.
+	Ramp *Ramp
+	// Rand picks the codons of CodonOptimize. Designs made with sources
+	// seeded alike are the same, the shared math/rand source is used when it
+	// is nil.
+	Rand *rand.Rand
+}
+
+func (options Options) forbidden() []string {
+	if options.Forbidden == nil {
+		return ForbiddenSequences()
+	}
+	return options.Forbidden
+}
+
+func (options Options) repeatLength() int {
+	if options.RepeatLength <= 0 {
+		return DefaultRepeatLength
+	}
+	return options.RepeatLength
+}
+
+func (options Options) hostKmer() int {
+	if options.HostKmer <= 0 {
+		return DefaultHostKmer
+	}
+	return options.HostKmer
+}
+
+// reach is the most bases a problem FixSequence removes can span, other than
+// repeats and alternative ORFs, so a change to a CDS only changes the other
+// problems that far from it.
+func (options Options) reach() int {
+	reach := options.hairpin().Window
+	lengths := []int{options.repeatLength(), options.hostKmer()}
+	if options.AntiSD != "" {
+		lengths = append(lengths, len(options.AntiSD)+InternalSDMaxSpacing+3)
+	}
+	for _, sequence := range options.forbidden() {
+		lengths = append(lengths, len(sequence))
+	}
+	for _, length := range lengths {
+		if length > reach {
+			reach = length
+		}
+	}
+	return reach
+}
+
+func (options Options) internalSDEnergy() float64 {
+	if options.InternalSDEnergy >= 0 {
+		return DefaultInternalSDEnergy
+	}
+	return options.InternalSDEnergy
+}
+
+func (options Options) hairpin() HairpinOptions {
+	hairpin := HairpinOptions{StemSize: options.HairpinStem, Window: options.HairpinWindow, MinLoop: DefaultHairpinLoop}
+	if hairpin.StemSize <= 0 {
+		hairpin.StemSize = DefaultHairpinStem
+	}
+	if hairpin.Window <= 0 {
+		hairpin.Window = DefaultHairpinWindow
+	}
+	return hairpin
+}
+
+// codonOptimize makes a CDS for a protein, with the ramp of the options when
+// they have one.
+func (options Options) codonOptimize(protein string, codonTable codon.Table) (string, error) {
+	if options.Ramp != nil {
+		return options.Ramp.Optimize(protein, codonTable, options.Rand)
+	}
+	return CodonOptimize(protein, codonTable, options.Rand)
+}
+
+// Result is a designed CDS.
+type Result struct {
+	// Optimized is the CDS made by CodonOptimize, before its problems were
+	// fixed.
+	Optimized string
+	// Sequence is the fixed CDS, ending with a TAA stop codon.
+	Sequence string
+	// LockedProblems describe the problems left in locked regions, which
+	// FixSequence doesn't change.
+	LockedProblems []string
+}
+
+// Design codon optimizes a protein with the codon table, and the ramp of the
+// options, and fixes the problems of the CDS.
+func Design(ctx context.Context, protein string, codonTable codon.Table, options Options) (Result, error) {
+	optimized, err := options.codonOptimize(protein, codonTable)
+	if err != nil {
+		return Result{}, err
+	}
+	fixed, lockedProblems, err := FixSequence(ctx, optimized, codonTable, options)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Optimized: optimized, Sequence: fixed, LockedProblems: lockedProblems}, nil
+}
+
+// CodonOptimize makes a CDS for a protein, picking each codon at random by
+// its weight in the codon table, and checks that it translates back to the
+// protein. It does the same as codon.Optimize, but with our own random
+// source: codon.Optimize seeds math/rand with the time, so its results can't
+// be made again.
+func CodonOptimize(protein string, codonTable codon.Table, random *rand.Rand) (string, error) {
+	// Poly generally makes Codon Optimization by receiving a list of protein sequences. We will be using the
+	// Eubacterial genetic code table 11, you could take a look at this table in
+	// https://www.ncbi.nlm.nih.gov/Taxonomy/Utils/wprintgc.cgi
+	optimizedSequence, err := optimizeCodons(protein, codonTable, random)
+	if err != nil {
+		return "", fmt.Errorf("could not optimize sequence: %w", err)
+	}
+
+	// Check if both translated sequences are equal
+	if err := checkTranslation(optimizedSequence, protein); err != nil {
+		return "", err
+	}
+	return optimizedSequence, nil
+}
+
+// checkTranslation checks that an optimized CDS translates back to its
+// protein.
+func checkTranslation(optimizedSequence string, protein string) error {
+	translated, err := codon.Translate(optimizedSequence, codon.GetCodonTable(11))
+	if err != nil {
+		return fmt.Errorf("could not translate the optimized sequence: %w", err)
+	}
+	if translated != protein {
+		return errors.New("the optimized sequence doesn't translate to the protein")
+	}
+	return nil
+}
+
+func optimizeCodons(aminoAcids string, codonTable codon.Table, random *rand.Rand) (string, error) {
+	if len(aminoAcids) == 0 {
+		return "", errors.New("empty amino acid string")
+	}
+	intn := rand.Intn
+	if random != nil {
+		intn = random.Intn
+	}
+	codons := make(map[string][]codon.Codon)
+	for _, aminoAcid := range codonTable.AminoAcids {
+		codons[aminoAcid.Letter] = aminoAcid.Codons
+	}
+
+	var optimizedSequence strings.Builder
+	for _, aminoAcid := range aminoAcids {
+		total := 0
+		for _, triplet := range codons[string(aminoAcid)] {
+			total += triplet.Weight
+		}
+		if total == 0 {
+			return "", fmt.Errorf("no codon with weight for amino acid %c", aminoAcid)
+		}
+
+		pick := intn(total)
+		for _, triplet := range codons[string(aminoAcid)] {
+			if pick < triplet.Weight {
+				optimizedSequence.WriteString(triplet.Triplet)
+				break
+			}
+			pick -= triplet.Weight
+		}
+	}
+	return optimizedSequence.String(), nil
+}
+
+// FixSequence changes codons of a CDS, using the codon table, until it has no
+// forbidden sequences, repeats, host homology or hairpins, unfolds its 5' end
+// when the options have a StartFold, and adds a TAA stop codon. Codons in
+// locked regions are never changed, and the problems left in them are
+// returned. When the context is done, FixSequence stops at the end of the
+// current round of synthesis.FixCds, or the current swap of
+// StartFold.Minimize, and returns its error.
+func FixSequence(ctx context.Context, sequence string, codonTable codon.Table, options Options) (string, []string, error) {
+	if err := options.hairpin().Validate(); err != nil {
+		return "", nil, err
+	}
+	functions := FixFunctions(options)
+
+	// Keep FixCds away from the locked regions, problems that overlap them are only reported
+	fixFunctions := functions
+	if len(options.LockedRegions) > 0 {
+		fixFunctions = nil
+		for _, function := range functions {
+			fixFunctions = append(fixFunctions, lockRegions(function, options.LockedRegions))
+		}
+	}
+
+	// FixCds can't be stopped, but it returns once a round has no suggestions, which inOrder makes when the
+	// context is done
+	sequence, _, err := synthesis.FixCds(":memory:", sequence, codonTable, []func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup){inOrder(ctx, fixFunctions)})
+	if err != nil {
+		return "", nil, fmt.Errorf("could not fix sequence: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return "", nil, err
+	}
+	if options.StartFold != nil {
+		if sequence, err = options.StartFold.Minimize(ctx, sequence, codonTable, options); err != nil {
+			return "", nil, err
+		}
+	}
+	// Because FixCds actually remove stop codon we will concatenate it
+	return sequence + "TAA", lockedRegionProblems(sequence, functions, options.LockedRegions), nil
+}
+
+// maxSuggestions is how many suggestions inOrder passes to synthesis.FixCds in
+// a round. FixCds only reads them once every function is done, so more than its
+// channel holds would block it.
+const maxSuggestions = 100
+
+// inOrder merges problematicSequenceFuncs into one that passes their
+// suggestions to synthesis.FixCds in a fixed order: the suggestions of each
+// function, sorted by codon, in the order of the functions. FixCds changes
+// codons in the order it gets suggestions, so a CDS is always fixed the same
+// way. Repeated suggestions are passed once, and no more than maxSuggestions
+// in a round, the others are found again in the next one. Once the context is
+// done no suggestions are passed, so FixCds stops.
+func inOrder(ctx context.Context, functions []func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup)) func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup) {
+	return func(sequence string, c chan synthesis.DnaSuggestion, wg *sync.WaitGroup) {
+		defer wg.Done()
+		if ctx.Err() != nil {
+			return
+		}
+		found := make([][]synthesis.DnaSuggestion, len(functions))
+		var functionsWg sync.WaitGroup
+		for i, function := range functions {
+			functionsWg.Add(1)
+			go func(i int, function func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup)) {
+				defer functionsWg.Done()
+				found[i] = findSuggestions(sequence, []func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup){function})
+			}(i, function)
+		}
+		functionsWg.Wait()
+		if ctx.Err() != nil {
+			return
+		}
+
+		passed := make(map[synthesis.DnaSuggestion]bool)
+		for _, suggestions := range found {
+			sort.Slice(suggestions, func(i, j int) bool {
+				return suggestionLess(suggestions[i], suggestions[j])
+			})
+			for _, suggestion := range suggestions {
+				if len(passed) == maxSuggestions {
+					return
+				}
+				if !passed[suggestion] {
+					passed[suggestion] = true
+					c <- suggestion
+				}
+			}
+		}
+	}
+}
+
+// suggestionLess orders suggestions by their codons, then by what they fix.
+func suggestionLess(first synthesis.DnaSuggestion, second synthesis.DnaSuggestion) bool {
+	switch {
+	case first.Start != second.Start:
+		return first.Start < second.Start
+	case first.End != second.End:
+		return first.End < second.End
+	case first.SuggestionType != second.SuggestionType:
+		return first.SuggestionType < second.SuggestionType
+	case first.Bias != second.Bias:
+		return first.Bias < second.Bias
+	}
+	return first.QuantityFixes < second.QuantityFixes
+}
+
+// FixFunctions are the problematicSequenceFuncs FixSequence passes to
+// synthesis.FixCds.
+func FixFunctions(options Options) []func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup) {
+	// Function#1: Remove unwanted sequences as restriction binding sites and homopolymers of length 5
+	removeSequenceFunc := synthesis.RemoveSequence(options.forbidden())
+
+	// Function#2: Remove secondary structures
+	removeSecondaryFunc := RemoveHairpin(options.hairpin())
+
+	// Function#3: Remove repetition greater than 10 inside the sequence
+	removeRepeatFunc := synthesis.RemoveRepeat(options.repeatLength())
+
+	functions := []func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup){removeSequenceFunc, removeRepeatFunc}
+
+	// Function#4: Remove repetitions between sequence and host genome
+	if options.HostKmers != nil {
+		functions = append(functions, synthesis.GlobalRemoveRepeat(options.hostKmer(), options.HostKmers))
+	}
+
+	// Function#5: Remove internal Shine-Dalgarnos that could start translation inside the CDS
+	if options.AntiSD != "" {
+		functions = append(functions, RemoveInternalSD(options.AntiSD, options.internalSDEnergy()))
+	}
+
+	// Function#6: Shorten long alternative ORFs in the other frames and on the other strand
+	if options.AlternativeORFCodons > 0 {
+		functions = append(functions, RemoveAlternativeORFs(options.AlternativeORFCodons))
+	}
+
+	// Function#7: Fix a GC content if necessary
+	//gcContentFixFunc := synthesis.GcContentFixer(0.4, 0.6)
+	return append(functions, removeSecondaryFunc)
+}
+
+// KmerTable makes the set of unique kmers of length k of a sequence, like a
+// host genome. The kmers of circular sequences, like bacterial genomes,
+// include the ones across their origin.
+func KmerTable(k int, sequence string, circular bool) map[string]bool {
+	kmers := make(map[string]bool)
+	if circular && k > 1 && k-1 <= len(sequence) {
+		sequence += sequence[:k-1]
+	}
+	for i := 0; i <= len(sequence)-k; i++ {
+		kmers[strings.ToUpper(sequence[i:i+k])] = true
+	}
+
+	return kmers
+}
+
+// MakePart lays out a Golden Gate part of the standard around a CDS, see
+// goldengate.MakePart.
+func MakePart(standard goldengate.Standard, partType string, cds string, options goldengate.FlankOptions) (goldengate.Part, error) {
+	return goldengate.MakePart(standard, partType, cds, options)
+}
+
+// ForbiddenSequences are the sequences FixSequence removes by default: the
+// sites of the Type IIS enzymes we assemble with, on both strands, PmeI and
+// homopolymers of 5 bases.
+func ForbiddenSequences() []string {
+	BsaI_bind_5prime := "GGTCTC" //5" GGTCTC N|      3"
+	//3" CCAGAG N NNNN| 5"
+	BsaI_bind_3prime := "GAGACC" //5" |NNNN N GAGACC 3"
+
+	BbsI_bind_5prime := "GAAGAC" //5" GAAGAC NN|
+	//3" CTTCTG NN NNNN|
+	BbsI_bind_3prime := "GTCTTC" //5" |NNNN NN GTCTTC 3"
+
+	BtgzI_bind_5prime := "GCGATG" //5" GCGATG NNN NNN NNN N|      3"
+	//3" CGCTAC NNN NNN NNN N NNNN| 5"
+	BtgzI_bind_3prime := "CATCGC" //5" |NNNN N NNN NNN NNN CATCGC 3"
+	//3"      |N NNN NNN NNN GTAGCG 5"
+
+	SapI_bind_5prime := "GCTCTTC" //5" GCTCTTC N|     3"
+	//3" CGAGAAG N NNN| 5"
+	SapI_bind_3prime := "GAAGAGC" //5" |NNN N GAAGAGC 3"
+	//3"     |N CTTCTCG 5"
+
+	BsmbI_bind_5prime := "CGTCTC" //5" CGTCTC N|      3"
+	//3" GCAGAG N NNNN| 5"
+	BsmbI_bind_3prime := "GAGACG" //5" |NNNN N GAGACG 3"
+	//3"      |N CTCTGC 5"
+
+	AarI_bind_5prime := "CACCTGC" //5" CACCTGC N NNN|       3"
+	//3" GTGGACG N NNN NNNN|  5"
+	AarI_bind_3prime := "GCAGGTG" //5" |NNNN NNN N GCAGGTG 3"
+	//3"      |NNN N CGTCCAC 5"
+
+	PmeI_bind := "GTTTAAAC" //5" GTTT|AAAC 3" blunt cutter
+
+	forbiddenSeqList := []string{BsaI_bind_5prime,
+		BsaI_bind_3prime,
+		BbsI_bind_5prime,
+		BbsI_bind_3prime,
+		SapI_bind_5prime,
+		SapI_bind_3prime,
+		BsmbI_bind_5prime,
+		BsmbI_bind_3prime,
+		BtgzI_bind_5prime,
+		BtgzI_bind_3prime,
+		AarI_bind_5prime,
+		AarI_bind_3prime,
+		PmeI_bind,
+		"AAAAA",
+		"CCCCC",
+		"GGGGG",
+		"TTTTT"}
+	return forbiddenSeqList
+}
@@ -0,0 +1,51 @@
+package design
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/Open-Science-Global/poly/io/fasta"
+	"github.com/Open-Science-Global/poly/transform/codon"
+)
+
+func TestDesignSameSeed(t *testing.T) {
+	codonTable := codon.ReadCodonJSON("../data/codon-table/bsub-ko7-cdss.json")
+	proteins := make(map[string]string)
+	for _, enzyme := range fasta.Read("../data/enzymes.fasta") {
+		proteins[enzyme.Name] = enzyme.Sequence
+	}
+
+	for _, name := range []string{"T4-PNK", "BsaI"} {
+		protein, ok := proteins[name]
+		if !ok {
+			t.Fatalf("no %s in ../data/enzymes.fasta", name)
+		}
+		var results []Result
+		for run := 0; run < 2; run++ {
+			options := Options{Rand: rand.New(rand.NewSource(1)), AntiSD: "GATCACCTCCTTA", AlternativeORFCodons: DefaultAlternativeORFCodons}
+			result, err := Design(context.Background(), protein, codonTable, options)
+			if err != nil {
+				t.Fatalf("could not design %s: %v", name, err)
+			}
+			results = append(results, result)
+		}
+		if results[0].Optimized != results[1].Optimized {
+			t.Errorf("%s was optimized differently with the same seed", name)
+		}
+		if results[0].Sequence != results[1].Sequence {
+			t.Errorf("%s was fixed differently with the same seed", name)
+		}
+	}
+}
+
+func TestFixSequenceStops(t *testing.T) {
+	codonTable := codon.ReadCodonJSON("../data/codon-table/bsub-ko7-cdss.json")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// Two BsaI sites, so there is something to fix
+	cds := "ATGGGTCTCAAAGGTCTCAAA"
+	if _, _, err := FixSequence(ctx, cds, codonTable, Options{StartFold: &StartFold{UTR: "AAAGAGGAGAAA"}}); err != context.Canceled {
+		t.Errorf("FixSequence with a done context returned %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,175 @@
+package design
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Open-Science-Global/poly/linearfold"
+	"github.com/Open-Science-Global/poly/synthesis"
+	"github.com/Open-Science-Global/poly/transform"
+	"github.com/Open-Science-Global/poly/transform/codon"
+)
+
+// DefaultStartFoldCodons is how many codons of a CDS StartFold folds with
+// the 5' UTR by default.
+const DefaultStartFoldCodons = 15
+
+// StartFold sets how FixSequence unfolds the 5' end of the mRNA: structure
+// from the RBS to the first codons hides them from the ribosome and lowers
+// expression more than structure anywhere else.
+type StartFold struct {
+	// UTR is the 5' UTR before the start codon, with the RBS.
+	UTR string `json:"utr"`
+	// Codons is how many codons of the CDS are folded with the UTR,
+	// DefaultStartFoldCodons when 0.
+	Codons int `json:"codons"`
+}
+
+func (fold StartFold) codons() int {
+	if fold.Codons <= 0 {
+		return DefaultStartFoldCodons
+	}
+	return fold.Codons
+}
+
+// window is the UTR and the first codons of a CDS.
+func (fold StartFold) window(cds string) string {
+	end := fold.codons() * 3
+	if end > len(cds) {
+		end = len(cds)
+	}
+	return strings.ToUpper(fold.UTR) + cds[:end]
+}
+
+// Score is the FoldScore of the UTR and the first codons of a CDS, lower for
+// less structure. It is the CONTRAfold score of the fold, not its free energy
+// in kcal/mol: lowering it is what raises the free energy.
+func (fold StartFold) Score(cds string) float64 {
+	return FoldScore(fold.window(cds))
+}
+
+// Minimize swaps synonymous codons of the first codons of a CDS, after the
+// start codon, for the ones that fold the least with the UTR. One codon is
+// swapped at a time, the one lowering the score the most, until no swap
+// lowers it. Swaps to codons the codon table never uses, in locked regions or
+// that would add any problem the options remove are not made, see
+// startProblems. When the context is done, Minimize stops before the next
+// swap and returns its error.
+func (fold StartFold) Minimize(ctx context.Context, cds string, codonTable codon.Table, options Options) (string, error) {
+	synonyms := make(map[string][]string)
+	for _, aminoAcid := range codonTable.AminoAcids {
+		var used []string
+		for _, triplet := range aminoAcid.Codons {
+			if triplet.Weight > 0 {
+				used = append(used, triplet.Triplet)
+			}
+		}
+		for _, triplet := range aminoAcid.Codons {
+			synonyms[triplet.Triplet] = used
+		}
+	}
+
+	codons := fold.codons()
+	if codons > len(cds)/3 {
+		codons = len(cds) / 3
+	}
+	problems := fold.startProblems(cds, options)
+	score := fold.Score(cds)
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		type swap struct {
+			sequence string
+			score    float64
+		}
+		var swaps []swap
+		for position := 1; position < codons; position++ {
+			if codonLocked(position, options.LockedRegions) {
+				continue
+			}
+			current := cds[position*3 : position*3+3]
+			for _, synonym := range synonyms[current] {
+				if synonym == current {
+					continue
+				}
+				swappedCds := cds[:position*3] + synonym + cds[position*3+3:]
+				if swappedScore := fold.Score(swappedCds); swappedScore < score {
+					swaps = append(swaps, swap{swappedCds, swappedScore})
+				}
+			}
+		}
+
+		// Take the best swap that adds no problems, problems are slower to find than folds
+		sort.SliceStable(swaps, func(i, j int) bool {
+			return swaps[i].score < swaps[j].score
+		})
+		swapped := false
+		for _, candidate := range swaps {
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			if candidateProblems := fold.startProblems(candidate.sequence, options); subset(candidateProblems, problems) {
+				cds, score, problems = candidate.sequence, candidate.score, candidateProblems
+				swapped = true
+				break
+			}
+		}
+		if !swapped {
+			return cds, nil
+		}
+	}
+}
+
+// startProblems finds the problems a swap in the first codons of a CDS can
+// change: the problems within reach of those codons, see Options.reach, and
+// the repeats and alternative ORFs of the whole CDS, as a repeat can have its
+// copies anywhere and an ORF can run from far away into the swapped codons.
+func (fold StartFold) startProblems(cds string, options Options) map[Problem]bool {
+	end := fold.codons()*3 + options.reach()
+	if end > len(cds) {
+		end = len(cds)
+	}
+	problems := make(map[Problem]bool)
+	for _, problem := range FindProblems(cds[:end], options) {
+		problems[problem] = true
+	}
+	global := []func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup){synthesis.RemoveRepeat(options.repeatLength())}
+	if options.AlternativeORFCodons > 0 {
+		global = append(global, RemoveAlternativeORFs(options.AlternativeORFCodons))
+	}
+	for _, suggestion := range findSuggestions(cds, global) {
+		problems[Problem{Start: suggestion.Start * 3, End: suggestion.End*3 + 3, Type: suggestion.SuggestionType}] = true
+	}
+	return problems
+}
+
+// subset checks that every problem of some is in all.
+func subset(some map[Problem]bool, all map[Problem]bool) bool {
+	for problem := range some {
+		if !all[problem] {
+			return false
+		}
+	}
+	return true
+}
+
+// foldMutex keeps linearfold to one fold at a time, as it folds in package
+// variables. Every fold of the toolkit, like the StartFold, Ramp and rbs
+// scores, goes through FoldScore so they all take it: call linearfold
+// nowhere else.
+var foldMutex sync.Mutex
+
+// FoldScore is the CONTRAfold score of the best fold of the mRNA of a
+// sequence, higher for more stable structures and around 0 for none.
+func FoldScore(sequence string) float64 {
+	if sequence == "" {
+		return 0
+	}
+	foldMutex.Lock()
+	defer foldMutex.Unlock()
+	_, score := linearfold.CONTRAfoldV2(transform.Transcription(sequence), linearfold.DefaultBeamSize)
+	return score
+}
@@ -0,0 +1,145 @@
+package design
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Open-Science-Global/poly/synthesis"
+)
+
+// HairpinOptions sets which hairpins FindHairpins looks for.
+type HairpinOptions struct {
+	// StemSize is the shortest stem, in bases on each arm.
+	StemSize int
+	// Window is the most bases from the start of the 5' arm to the end of the
+	// 3' arm.
+	Window int
+	// MinLoop is the fewest unpaired bases between the arms.
+	MinLoop int
+	// Mismatches is how many bases of the StemSize bases of each arm may not
+	// pair.
+	Mismatches int
+}
+
+// Validate checks that hairpins of the options fit in their window, as
+// FindHairpins would find none otherwise.
+func (options HairpinOptions) Validate() error {
+	switch {
+	case options.StemSize <= 0:
+		return fmt.Errorf("hairpin stems need at least one base, not %d", options.StemSize)
+	case options.MinLoop < 0 || options.Mismatches < 0:
+		return fmt.Errorf("hairpin loops and mismatches can't be negative, got %d and %d", options.MinLoop, options.Mismatches)
+	case options.Window < 2*options.StemSize+options.MinLoop:
+		return fmt.Errorf("a hairpin window of %d bases can't hold two arms of %d bases and a loop of %d", options.Window, options.StemSize, options.MinLoop)
+	}
+	return nil
+}
+
+// HairpinStem is a stem whose 5' arm, from FiveStart to FiveEnd, pairs with its 3'
+// arm, from ThreeStart to ThreeEnd. Starts are 0-based and ends not included.
+type HairpinStem struct {
+	FiveStart  int
+	FiveEnd    int
+	ThreeStart int
+	ThreeEnd   int
+	Mismatches int
+}
+
+// Length is the length of each arm.
+func (hairpin HairpinStem) Length() int {
+	return hairpin.FiveEnd - hairpin.FiveStart
+}
+
+// Loop is the number of bases between the arms.
+func (hairpin HairpinStem) Loop() int {
+	return hairpin.ThreeStart - hairpin.FiveEnd
+}
+
+// CountMismatches counts the bases of the 5' arm of the stem that don't pair
+// with its 3' arm in the sequence, in upper case.
+func (hairpin HairpinStem) CountMismatches(sequence string) int {
+	return mismatches(sequence, hairpin.FiveStart, hairpin.ThreeStart, hairpin.Length())
+}
+
+var complements = map[byte]byte{'A': 'T', 'T': 'A', 'C': 'G', 'G': 'C'}
+
+// Pairs checks if two bases, in upper case, make a Watson-Crick pair.
+func Pairs(first byte, second byte) bool {
+	return complements[first] == second
+}
+
+// FindHairpins finds every pair of arms in the sequence that can make a
+// hairpin, up to its last base. Pairs of StemSize bases that continue each
+// other, one base further in towards the loop on both arms, are reported as a
+// single longer stem.
+func FindHairpins(sequence string, options HairpinOptions) ([]HairpinStem, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	stem := options.StemSize
+
+	sequence = strings.ToUpper(sequence)
+	var hairpins []HairpinStem
+	// stems holds the hairpin each diagonal, the sum of the starts of both
+	// arms, is currently extending
+	stems := make(map[int]int)
+	for five := 0; five+stem <= len(sequence); five++ {
+		for three := five + stem + options.MinLoop; three+stem <= len(sequence) && three+stem-five <= options.Window; three++ {
+			if !pairsWithin(sequence, five, three, stem, options.Mismatches) {
+				continue
+			}
+			if index, ok := stems[five+three]; ok && hairpins[index].FiveEnd == five+stem-1 && hairpins[index].ThreeStart == three+1 {
+				hairpins[index].FiveEnd = five + stem
+				hairpins[index].ThreeStart = three
+				continue
+			}
+			stems[five+three] = len(hairpins)
+			hairpins = append(hairpins, HairpinStem{FiveStart: five, FiveEnd: five + stem, ThreeStart: three, ThreeEnd: three + stem})
+		}
+	}
+	for i := range hairpins {
+		hairpins[i].Mismatches = hairpins[i].CountMismatches(sequence)
+	}
+	return hairpins, nil
+}
+
+// pairsWithin checks if the arm of length stem at five pairs with the arm at
+// three with at most allowed mismatches, stopping at the first one over.
+func pairsWithin(sequence string, five int, three int, stem int, allowed int) bool {
+	for i := 0; i < stem; i++ {
+		if !Pairs(sequence[five+i], sequence[three+stem-1-i]) {
+			if allowed == 0 {
+				return false
+			}
+			allowed--
+		}
+	}
+	return true
+}
+
+// mismatches counts the bases of the arm of length stem at five that don't
+// pair with the arm at three.
+func mismatches(sequence string, five int, three int, stem int) int {
+	count := 0
+	for i := 0; i < stem; i++ {
+		if !Pairs(sequence[five+i], sequence[three+stem-1-i]) {
+			count++
+		}
+	}
+	return count
+}
+
+// RemoveHairpin is a problematicSequenceFunc for synthesis.FixCds that
+// suggests changing the codons of the 5' arm of each hairpin FindHairpins
+// finds, so its arms stop pairing. Options that find no hairpin suggest
+// nothing, see HairpinOptions.Validate.
+func RemoveHairpin(options HairpinOptions) func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup) {
+	return func(sequence string, c chan synthesis.DnaSuggestion, wg *sync.WaitGroup) {
+		defer wg.Done()
+		hairpins, _ := FindHairpins(sequence, options)
+		for _, hairpin := range hairpins {
+			c <- synthesis.DnaSuggestion{Start: hairpin.FiveStart / 3, End: (hairpin.FiveEnd - 1) / 3, Bias: "NA", QuantityFixes: 1, SuggestionType: "Remove hairpin"}
+		}
+	}
+}
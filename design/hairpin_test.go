@@ -0,0 +1,56 @@
+package design
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindHairpins(t *testing.T) {
+	options := HairpinOptions{StemSize: 6, Window: 20, MinLoop: 3}
+	mismatched := options
+	mismatched.Mismatches = 1
+	narrow := options
+	narrow.Window = 16
+	tests := []struct {
+		name     string
+		sequence string
+		options  HairpinOptions
+		want     []HairpinStem
+	}{
+		{"stem", "GGACTCAAAAGAGTCC", options, []HairpinStem{{FiveStart: 0, FiveEnd: 6, ThreeStart: 10, ThreeEnd: 16}}},
+		{"stem at the 3' end", "CCCCCCCCCCggactcaaaagagtcc", options, []HairpinStem{{FiveStart: 10, FiveEnd: 16, ThreeStart: 20, ThreeEnd: 26}}},
+		{"longer stem", "AGGACTCTAAAAAGAGTCCT", options, []HairpinStem{{FiveStart: 0, FiveEnd: 8, ThreeStart: 12, ThreeEnd: 20}}},
+		{"longer stem than the window", "AGGACTCTAAAAAGAGTCCT", narrow, []HairpinStem{{FiveStart: 2, FiveEnd: 8, ThreeStart: 12, ThreeEnd: 18}}},
+		{"mismatch", "GGACTCAAAAGAGACC", options, nil},
+		{"allowed mismatch", "GGACTCAAAAGAGACC", mismatched, []HairpinStem{{FiveStart: 0, FiveEnd: 6, ThreeStart: 10, ThreeEnd: 16, Mismatches: 1}}},
+		{"short loop", "GGACTCAAGAGTCC", options, nil},
+	}
+	for _, test := range tests {
+		hairpins, err := FindHairpins(test.sequence, test.options)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(hairpins, test.want) {
+			t.Errorf("%s: found %v, want %v", test.name, hairpins, test.want)
+		}
+	}
+}
+
+func TestHairpinOptionsValidate(t *testing.T) {
+	tests := []struct {
+		options HairpinOptions
+		valid   bool
+	}{
+		{HairpinOptions{StemSize: 6, Window: 15, MinLoop: 3}, true},
+		{HairpinOptions{StemSize: 6, Window: 14, MinLoop: 3}, false},
+		{HairpinOptions{StemSize: 0, Window: 15}, false},
+		{HairpinOptions{StemSize: 6, Window: 0}, false},
+		{HairpinOptions{StemSize: 6, Window: 15, Mismatches: -1}, false},
+	}
+	for _, test := range tests {
+		if err := test.options.Validate(); (err == nil) != test.valid {
+			t.Errorf("%+v: got %v, want valid: %v", test.options, err, test.valid)
+		}
+	}
+}
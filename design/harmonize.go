@@ -0,0 +1,181 @@
+package design
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+
+	"github.com/Open-Science-Global/poly/io/fasta"
+	"github.com/Open-Science-Global/poly/transform/codon"
+)
+
+// NativeCDS is the CDS of a protein in the organism it comes from, with the
+// codon table of that organism, to harmonize it for the host.
+type NativeCDS struct {
+	Sequence    string
+	SourceTable codon.Table
+}
+
+// nativeCDSConfig is how the native CDS of an enzyme is written: a FASTA file
+// of the CDS and a FASTA file of CDSs of its source organism to make the
+// source codon table from.
+type nativeCDSConfig struct {
+	Fasta      string `json:"fasta"`
+	SourceCdss string `json:"source_cdss"`
+}
+
+// ReadNativeCDSs reads the native CDS of each enzyme from a json file like
+// {"Pfu-Sso7d": {"fasta": "data/native/pfu-sso7d.fasta", "source_cdss": "data/native/pfu-cdss.fasta"}}. A missing
+// file means no enzyme can be harmonized.
+func ReadNativeCDSs(path string) (map[string]NativeCDS, error) {
+	natives := make(map[string]NativeCDS)
+	file, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return natives, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var configs map[string]nativeCDSConfig
+	if err := json.Unmarshal(file, &configs); err != nil {
+		return nil, err
+	}
+	for enzyme, config := range configs {
+		// fasta.Read doesn't return its errors
+		for _, path := range []string{config.Fasta, config.SourceCdss} {
+			if _, err := os.Stat(path); err != nil {
+				return nil, fmt.Errorf("native CDS of %s: %w", enzyme, err)
+			}
+		}
+		records := fasta.Read(config.Fasta)
+		if len(records) == 0 {
+			return nil, fmt.Errorf("native CDS of %s: no sequence in %s", enzyme, config.Fasta)
+		}
+		var cdss []string
+		for _, record := range fasta.Read(config.SourceCdss) {
+			cdss = append(cdss, record.Sequence)
+		}
+		natives[enzyme] = NativeCDS{Sequence: strings.ToUpper(records[0].Sequence), SourceTable: CodonTableFromCDSs(cdss)}
+	}
+	return natives, nil
+}
+
+// CodonTableFromCDSs makes a codon table of the bacterial genetic code
+// weighted by the codon usage of the CDSs of an organism. OptimizeTable
+// changes the weights of the table it is called on, and codon.GetCodonTable
+// always returns the same table, so it is called on a copy: otherwise every
+// table made would end up with the weights of the last one.
+func CodonTableFromCDSs(cdss []string) codon.Table {
+	bacterial := codon.GetCodonTable(11)
+	table := codon.Table{StartCodons: bacterial.StartCodons, StopCodons: bacterial.StopCodons}
+	for _, aminoAcid := range bacterial.AminoAcids {
+		table.AminoAcids = append(table.AminoAcids, codon.AminoAcid{Letter: aminoAcid.Letter, Codons: append([]codon.Codon{}, aminoAcid.Codons...)})
+	}
+	return table.OptimizeTable(strings.Join(cdss, ""))
+}
+
+// Harmonize picks, for each codon of a native CDS, the synonymous codon whose
+// usage in the target table is closest to the usage of the native codon in
+// the source table. Rare codons stay rare and common ones common, so clusters
+// of rare codons that slow translation down, which some proteins need to fold
+// their domains, are kept in the host. The start codon is read as M, and the
+// final stop codon is harmonized like the others, so the CDS translates to the
+// whole protein, stop included, like the CDSs CodonOptimize makes.
+func Harmonize(native string, source codon.Table, target codon.Table) (string, error) {
+	native = strings.ToUpper(native)
+	if len(native) == 0 || len(native)%3 != 0 {
+		return "", errors.New("the native CDS is not made of whole codons")
+	}
+
+	sourceUsage := codonUsage(source)
+	targetUsage := codonUsage(target)
+	letters := make(map[string]string)
+	synonyms := make(map[string][]codon.Codon)
+	for _, aminoAcid := range target.AminoAcids {
+		synonyms[aminoAcid.Letter] = aminoAcid.Codons
+		for _, triplet := range aminoAcid.Codons {
+			letters[triplet.Triplet] = aminoAcid.Letter
+		}
+	}
+	starts := make(map[string]bool)
+	for _, start := range source.StartCodons {
+		starts[start] = true
+	}
+
+	var harmonized strings.Builder
+	for position := 0; position < len(native); position += 3 {
+		triplet := native[position : position+3]
+		letter, ok := letters[triplet]
+		if position == 0 && starts[triplet] {
+			letter, ok = "M", true
+		}
+		if !ok {
+			return "", fmt.Errorf("unknown codon %s at %d of the native CDS", triplet, position)
+		}
+		if letter == "*" && position != len(native)-3 {
+			return "", fmt.Errorf("stop codon %s at %d of the native CDS", triplet, position)
+		}
+
+		best, bestDistance := "", math.Inf(1)
+		for _, synonym := range synonyms[letter] {
+			usage, ok := targetUsage[synonym.Triplet]
+			if !ok {
+				continue
+			}
+			distance := math.Abs(usage - sourceUsage[triplet])
+			// Ties go to the codon more used in the target
+			if distance < bestDistance || (distance == bestDistance && usage > targetUsage[best]) {
+				best, bestDistance = synonym.Triplet, distance
+			}
+		}
+		if best == "" {
+			return "", fmt.Errorf("no codon with weight for amino acid %s", letter)
+		}
+		harmonized.WriteString(best)
+	}
+	return harmonized.String(), nil
+}
+
+// codonUsage is the share of each codon among the codons of its amino acid
+// in a codon table. Codons without weight are left out.
+func codonUsage(codonTable codon.Table) map[string]float64 {
+	usage := make(map[string]float64)
+	for _, aminoAcid := range codonTable.AminoAcids {
+		total := 0
+		for _, triplet := range aminoAcid.Codons {
+			total += triplet.Weight
+		}
+		for _, triplet := range aminoAcid.Codons {
+			if triplet.Weight > 0 {
+				usage[triplet.Triplet] = float64(triplet.Weight) / float64(total)
+			}
+		}
+	}
+	return usage
+}
+
+// Harmonizer is an optimizer for a Job that harmonizes the native CDS of its
+// protein for the target codon table, see Harmonize. The harmonized CDS must
+// translate to the whole protein, stop included.
+func Harmonizer(native NativeCDS, target codon.Table) func(protein string, random *rand.Rand) (string, error) {
+	return func(protein string, _ *rand.Rand) (string, error) {
+		harmonized, err := Harmonize(native.Sequence, native.SourceTable, target)
+		if err != nil {
+			return "", fmt.Errorf("could not harmonize sequence: %w", err)
+		}
+		translated, err := codon.Translate(harmonized, codon.GetCodonTable(11))
+		if err != nil {
+			return "", fmt.Errorf("could not translate the harmonized sequence: %w", err)
+		}
+		if translated != protein {
+			return "", errors.New("the native CDS doesn't code for the protein")
+		}
+		return harmonized, nil
+	}
+}
@@ -0,0 +1,88 @@
+package design
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/Open-Science-Global/poly/io/fasta"
+	"github.com/Open-Science-Global/poly/transform/codon"
+)
+
+func TestHarmonize(t *testing.T) {
+	source := codon.Table{StartCodons: []string{"ATG", "GTG"}, AminoAcids: []codon.AminoAcid{
+		{Letter: "M", Codons: []codon.Codon{{Triplet: "ATG", Weight: 1}}},
+		{Letter: "L", Codons: []codon.Codon{{Triplet: "CTG", Weight: 90}, {Triplet: "TTA", Weight: 10}}},
+		{Letter: "*", Codons: []codon.Codon{{Triplet: "TAA", Weight: 20}, {Triplet: "TGA", Weight: 80}}},
+	}}
+	target := codon.Table{StartCodons: []string{"ATG"}, AminoAcids: []codon.AminoAcid{
+		{Letter: "M", Codons: []codon.Codon{{Triplet: "ATG", Weight: 1}}},
+		{Letter: "L", Codons: []codon.Codon{{Triplet: "CTG", Weight: 15}, {Triplet: "TTA", Weight: 85}}},
+		{Letter: "*", Codons: []codon.Codon{{Triplet: "TAA", Weight: 70}, {Triplet: "TGA", Weight: 30}}},
+	}}
+	tests := []struct {
+		name    string
+		native  string
+		want    string
+		wantErr bool
+	}{
+		{"common codons stay common", "ATGCTGTGA", "ATGTTATAA", false},
+		{"rare codons stay rare", "ATGTTATAA", "ATGCTGTGA", false},
+		{"alternative start", "GTGCTGTAA", "ATGTTATGA", false},
+		{"without a stop", "ATGCTG", "ATGTTA", false},
+		{"internal stop", "ATGTAACTG", "", true},
+		{"unknown codon", "ATGCCCTAA", "", true},
+		{"not whole codons", "ATGCT", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Harmonize(test.native, source, target)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Harmonize(%s) error = %v, want an error: %t", test.native, err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("Harmonize(%s) = %s, want %s", test.native, got, test.want)
+			}
+		})
+	}
+}
+
+func TestHarmonizer(t *testing.T) {
+	var protein string
+	for _, enzyme := range fasta.Read("../data/enzymes.fasta") {
+		if enzyme.Name == "Pfu-Sso7d" {
+			protein = enzyme.Sequence
+		}
+	}
+	if protein == "" {
+		t.Fatal("no Pfu-Sso7d in ../data/enzymes.fasta")
+	}
+	// We don't have the native CDS of Pfu-Sso7d, so one made with the E. coli
+	// table stands in for it
+	source := codon.ReadCodonJSON("../data/codon-table/ecoli-k12-cdss.json")
+	target := codon.ReadCodonJSON("../data/codon-table/bsub-ko7-cdss.json")
+	native, err := CodonOptimize(protein, source, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("could not make a CDS of Pfu-Sso7d: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		protein string
+		wantErr bool
+	}{
+		{"whole protein", protein, false},
+		{"protein without its stop", protein[:len(protein)-1], true},
+		{"other protein", "M" + protein[2:], true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			harmonized, err := Harmonizer(NativeCDS{Sequence: native, SourceTable: source}, target)(test.protein, nil)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Harmonizer error = %v, want an error: %t", err, test.wantErr)
+			}
+			if err == nil && len(harmonized) != len(native) {
+				t.Errorf("harmonized CDS has %d bases, the native one %d", len(harmonized), len(native))
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package design
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Open-Science-Global/poly"
+	"github.com/Open-Science-Global/poly/io/genbank"
+	"github.com/Open-Science-Global/poly/synthesis"
+)
+
+// LockedRegion is a stretch of a CDS that FixSequence must not change, like a His-tag, a TEV site or the overhang of
+// a C-terminal tag. Start and End are nucleotide positions in the CDS, End not included.
+type LockedRegion struct {
+	Name  string `json:"name"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// lockedRegionsConfig is how the locked regions of an enzyme are written: by coordinates, by the names of features to
+// lock in a GenBank file of its CDS, or both.
+type lockedRegionsConfig struct {
+	Regions  []LockedRegion `json:"regions"`
+	Genbank  string         `json:"genbank"`
+	Features []string       `json:"features"`
+}
+
+// ReadLockedRegions reads the locked regions of each enzyme from a json file like
+// {"Pfu-Sso7d": {"regions": [{"name": "10xHis", "start": 2400, "end": 2430}], "genbank": "data/pfu-sso7d.gb",
+// "features": ["TEV"]}}. A missing file means nothing is locked.
+func ReadLockedRegions(path string) (map[string][]LockedRegion, error) {
+	lockedRegions := make(map[string][]LockedRegion)
+	file, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return lockedRegions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var configs map[string]lockedRegionsConfig
+	if err := json.Unmarshal(file, &configs); err != nil {
+		return nil, err
+	}
+	for enzyme, config := range configs {
+		lockedRegions[enzyme] = config.Regions
+		if config.Genbank != "" {
+			lockedRegions[enzyme] = append(lockedRegions[enzyme], LockedRegionsFromFeatures(genbank.Read(config.Genbank), config.Features)...)
+		}
+	}
+	return lockedRegions, nil
+}
+
+// LockedRegionsFromFeatures locks every feature of a GenBank sequence whose label, note or type contains one of the
+// names, e.g. "His" or "TEV".
+func LockedRegionsFromFeatures(sequence poly.Sequence, names []string) []LockedRegion {
+	var lockedRegions []LockedRegion
+	for _, feature := range sequence.Features {
+		description := strings.ToLower(feature.Type + " " + feature.Attributes["label"] + " " + feature.Attributes["note"])
+		for _, name := range names {
+			if strings.Contains(description, strings.ToLower(name)) {
+				label := feature.Attributes["label"]
+				if label == "" {
+					label = name
+				}
+				lockedRegions = append(lockedRegions, LockedRegion{label, feature.SequenceLocation.Start, feature.SequenceLocation.End})
+				break
+			}
+		}
+	}
+	return lockedRegions
+}
+
+// lockRegions wraps a problematicSequenceFunc so its suggestions never reach a codon in a locked region. Suggestions
+// that overlap a locked region are trimmed to their unlocked codons, and the ones without any codon left to change are
+// not passed to FixCds at all.
+func lockRegions(function func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup), lockedRegions []LockedRegion) func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup) {
+	return func(sequence string, c chan synthesis.DnaSuggestion, wg *sync.WaitGroup) {
+		defer wg.Done()
+		for _, suggestion := range findSuggestions(sequence, []func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup){function}) {
+			for _, unlocked := range trimSuggestion(suggestion, lockedRegions) {
+				c <- unlocked
+			}
+		}
+	}
+}
+
+// lockedRegionProblems looks for problems in the whole sequence and describes the ones that overlap a locked region,
+// which are left for us to check since FixSequence can't change them.
+func lockedRegionProblems(sequence string, functions []func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup), lockedRegions []LockedRegion) []string {
+	found := make(map[string]bool)
+	for _, suggestion := range findSuggestions(sequence, functions) {
+		for _, region := range lockedRegions {
+			if suggestion.Start*3 < region.End && suggestion.End*3+3 > region.Start {
+				found[fmt.Sprintf("%s at codons %d-%d overlaps %s", suggestion.SuggestionType, suggestion.Start, suggestion.End, region.Name)] = true
+				break
+			}
+		}
+	}
+
+	var problems []string
+	for problem := range found {
+		problems = append(problems, problem)
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// findSuggestions runs problematicSequenceFuncs the same way FixCds does and collects their suggestions.
+func findSuggestions(sequence string, functions []func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup)) []synthesis.DnaSuggestion {
+	suggestions := make(chan synthesis.DnaSuggestion, 100)
+	var wg sync.WaitGroup
+	for _, function := range functions {
+		wg.Add(1)
+		go function(sequence, suggestions, &wg)
+	}
+	go func() {
+		wg.Wait()
+		close(suggestions)
+	}()
+
+	var suggestionsList []synthesis.DnaSuggestion
+	for suggestion := range suggestions {
+		suggestionsList = append(suggestionsList, suggestion)
+	}
+	return suggestionsList
+}
+
+// trimSuggestion splits a suggestion into the runs of codons, between Start and End, that are not in a locked region.
+func trimSuggestion(suggestion synthesis.DnaSuggestion, lockedRegions []LockedRegion) []synthesis.DnaSuggestion {
+	var trimmed []synthesis.DnaSuggestion
+	runStart := -1
+	for position := suggestion.Start; position <= suggestion.End+1; position++ {
+		free := position <= suggestion.End && !codonLocked(position, lockedRegions)
+		switch {
+		case free && runStart < 0:
+			runStart = position
+		case !free && runStart >= 0:
+			run := suggestion
+			run.Start, run.End = runStart, position-1
+			trimmed = append(trimmed, run)
+			runStart = -1
+		}
+	}
+	return trimmed
+}
+
+// codonLocked checks if any base of the codon at a codon position is in a locked region.
+func codonLocked(position int, lockedRegions []LockedRegion) bool {
+	for _, region := range lockedRegions {
+		if position*3 < region.End && position*3+3 > region.Start {
+			return true
+		}
+	}
+	return false
+}
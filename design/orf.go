@@ -0,0 +1,143 @@
+package design
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/Open-Science-Global/poly/synthesis"
+	"github.com/Open-Science-Global/poly/transform"
+)
+
+// DefaultAlternativeORFCodons is the length, in codons, from which an
+// alternative ORF counts as long.
+const DefaultAlternativeORFCodons = 50
+
+// stopCodons are the stop codons of bacteria.
+var stopCodons = []string{"TAA", "TAG", "TGA"}
+
+// ORF is an open reading frame of a CDS other than the CDS itself, from its
+// first start codon to its stop codon, or to the end of the CDS when it has
+// none there. Start and End, not included, are positions on the given strand
+// even for ORFs of the Reverse strand. Frame is 1 or 2 for the frames shifted
+// by that many bases and 0 to 2 for the frames of the reverse strand, from
+// its own start.
+type ORF struct {
+	Start   int  `json:"start"`
+	End     int  `json:"end"`
+	Frame   int  `json:"frame"`
+	Reverse bool `json:"reverse"`
+	Codons  int  `json:"codons"`
+}
+
+// AlternativeORFs finds the ORFs of a CDS of at least minCodons codons, stop
+// codon included, in the shifted frames of the given strand and the frames of
+// the reverse strand. Starts after the first one of an ORF are part of it.
+func AlternativeORFs(cds string, minCodons int) []ORF {
+	cds = strings.ToUpper(cds)
+	var orfs []ORF
+	for _, frame := range []int{1, 2} {
+		orfs = append(orfs, frameORFs(cds, frame, false, minCodons)...)
+	}
+	reverse := transform.ReverseComplement(cds)
+	for frame := 0; frame < 3; frame++ {
+		for _, orf := range frameORFs(reverse, frame, true, minCodons) {
+			orf.Start, orf.End = len(cds)-orf.End, len(cds)-orf.Start
+			orfs = append(orfs, orf)
+		}
+	}
+	return orfs
+}
+
+func frameORFs(sequence string, frame int, reverse bool, minCodons int) []ORF {
+	var orfs []ORF
+	start := -1
+	for position := frame; position+3 <= len(sequence); position += 3 {
+		codon := sequence[position : position+3]
+		if start < 0 && isCodon(codon, startCodons) {
+			start = position
+		}
+		if start >= 0 && isCodon(codon, stopCodons) {
+			if codons := (position + 3 - start) / 3; codons >= minCodons {
+				orfs = append(orfs, ORF{Start: start, End: position + 3, Frame: frame, Reverse: reverse, Codons: codons})
+			}
+			start = -1
+		}
+	}
+	if start >= 0 {
+		end := len(sequence) - (len(sequence)-start)%3
+		if codons := (end - start) / 3; codons >= minCodons {
+			orfs = append(orfs, ORF{Start: start, End: end, Frame: frame, Reverse: reverse, Codons: codons})
+		}
+	}
+	return orfs
+}
+
+func isCodon(codon string, codons []string) bool {
+	for _, candidate := range codons {
+		if codon == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// ORFAnalysis is the alternative ORFs and out of frame stop codons of a CDS.
+type ORFAnalysis struct {
+	// MinCodons is the length of the AlternativeORFs, LongestORF the codons
+	// of the longest alternative ORF of any length.
+	MinCodons       int   `json:"min_codons"`
+	AlternativeORFs []ORF `json:"alternative_orfs"`
+	LongestORF      int   `json:"longest_orf"`
+	// OutOfFrameStops are the stop codons of the shifted frames of the CDS,
+	// which end the translation of frameshifted ribosomes early, and
+	// StopDensity their number for every 100 codons of the CDS.
+	OutOfFrameStops int     `json:"out_of_frame_stops"`
+	StopDensity     float64 `json:"stop_density"`
+}
+
+// AnalyzeORFs finds the alternative ORFs of a CDS of at least minCodons
+// codons, DefaultAlternativeORFCodons when 0, and counts its out of frame
+// stop codons.
+func AnalyzeORFs(cds string, minCodons int) ORFAnalysis {
+	if minCodons <= 0 {
+		minCodons = DefaultAlternativeORFCodons
+	}
+	cds = strings.ToUpper(cds)
+	analysis := ORFAnalysis{MinCodons: minCodons, AlternativeORFs: []ORF{}}
+	for _, orf := range AlternativeORFs(cds, 1) {
+		if orf.Codons >= minCodons {
+			analysis.AlternativeORFs = append(analysis.AlternativeORFs, orf)
+		}
+		if orf.Codons > analysis.LongestORF {
+			analysis.LongestORF = orf.Codons
+		}
+	}
+	for _, frame := range []int{1, 2} {
+		for position := frame; position+3 <= len(cds); position += 3 {
+			if isCodon(cds[position:position+3], stopCodons) {
+				analysis.OutOfFrameStops++
+			}
+		}
+	}
+	if codons := len(cds) / 3; codons > 0 {
+		analysis.StopDensity = 100 * float64(analysis.OutOfFrameStops) / float64(codons)
+	}
+	return analysis
+}
+
+// RemoveAlternativeORFs is a problematicSequenceFunc for synthesis.FixCds
+// that suggests changing the codons of the start codon of each alternative
+// ORF of at least minCodons codons, see AlternativeORFs, so it starts later
+// or not at all.
+func RemoveAlternativeORFs(minCodons int) func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup) {
+	return func(sequence string, c chan synthesis.DnaSuggestion, wg *sync.WaitGroup) {
+		defer wg.Done()
+		for _, orf := range AlternativeORFs(sequence, minCodons) {
+			start := orf.Start
+			if orf.Reverse {
+				start = orf.End - 3
+			}
+			c <- synthesis.DnaSuggestion{Start: start / 3, End: (start + 2) / 3, Bias: "NA", QuantityFixes: (start+2)/3 - start/3 + 1, SuggestionType: "Remove alternative ORF"}
+		}
+	}
+}
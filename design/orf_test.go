@@ -0,0 +1,29 @@
+package design
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeORFs(t *testing.T) {
+	// An ORF of 7 codons in the frame shifted by one base, and the start of
+	// one on the reverse strand at the 5' end
+	cds := "CATG" + "CCCCCCCCCCCCCCC" + "TGACC"
+	shifted := ORF{Start: 1, End: 22, Frame: 1, Codons: 7}
+	reverse := ORF{Start: 0, End: 3, Frame: 0, Reverse: true, Codons: 1}
+	tests := []struct {
+		cds       string
+		minCodons int
+		want      ORFAnalysis
+	}{
+		{cds, 1, ORFAnalysis{MinCodons: 1, AlternativeORFs: []ORF{shifted, reverse}, LongestORF: 7, OutOfFrameStops: 1, StopDensity: 12.5}},
+		{cds, 5, ORFAnalysis{MinCodons: 5, AlternativeORFs: []ORF{shifted}, LongestORF: 7, OutOfFrameStops: 1, StopDensity: 12.5}},
+		{cds, 0, ORFAnalysis{MinCodons: DefaultAlternativeORFCodons, AlternativeORFs: []ORF{}, LongestORF: 7, OutOfFrameStops: 1, StopDensity: 12.5}},
+		{"ATG" + "CCCCCCCCCCCCCCCCCC" + "TAA", 1, ORFAnalysis{MinCodons: 1, AlternativeORFs: []ORF{}}},
+	}
+	for _, test := range tests {
+		if analysis := AnalyzeORFs(test.cds, test.minCodons); !reflect.DeepEqual(analysis, test.want) {
+			t.Errorf("AnalyzeORFs(%s, %d) = %+v, want %+v", test.cds, test.minCodons, analysis, test.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package design
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Open-Science-Global/poly/transform/codon"
+)
+
+// Job is a protein to design with a codon table, like one enzyme with one
+// codon table strategy.
+type Job struct {
+	Name       string
+	Protein    string
+	CodonTable codon.Table
+	Options    Options
+	// Optimize picks the codons of the protein, like Harmonizer does,
+	// CodonOptimize with the codon table, or the ramp of the options, when it
+	// is nil. The codon table is
+	// still the one problems are fixed with.
+	Optimize func(protein string, random *rand.Rand) (string, error)
+}
+
+// optimize picks the codons of the job.
+func (job Job) optimize() (string, error) {
+	if job.Optimize != nil {
+		return job.Optimize(job.Protein, job.Options.Rand)
+	}
+	return job.Options.codonOptimize(job.Protein, job.CodonTable)
+}
+
+// JobResult is the design of a Job, or the error that stopped it.
+type JobResult struct {
+	Job      Job
+	Result   Result
+	Err      error
+	Duration time.Duration
+}
+
+// PoolOptions sets how DesignAll runs its jobs.
+type PoolOptions struct {
+	// Workers is how many jobs are fixed at the same time, 1 when it is 0.
+	Workers int
+	// Timeout stops any job that takes longer, no timeout when it is 0. The job
+	// stops at the end of its current round of fixes, see FixSequence.
+	Timeout time.Duration
+	// Progress is called after each job, from one goroutine at a time, with
+	// the number of finished jobs.
+	Progress func(done int, total int, result JobResult)
+}
+
+func (options PoolOptions) workers() int {
+	if options.Workers <= 0 {
+		return 1
+	}
+	return options.Workers
+}
+
+// DesignAll designs every job on a pool of workers and returns their results
+// in the order of the jobs, whatever order they finish in. Codons are picked
+// for every job first, in order, so jobs sharing a seeded Rand get the same
+// designs with any number of workers. Then the problems of each CDS are fixed
+// by the workers. Jobs not finished when the context is done fail with its
+// error.
+func DesignAll(ctx context.Context, jobs []Job, options PoolOptions) []JobResult {
+	results := make([]JobResult, len(jobs))
+	for i, job := range jobs {
+		results[i].Job = job
+		results[i].Result.Optimized, results[i].Err = job.optimize()
+	}
+
+	indexes := make(chan int)
+	var progress sync.Mutex
+	done := 0
+	var wg sync.WaitGroup
+	for worker := 0; worker < options.workers(); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				result := &results[i]
+				if result.Err == nil {
+					result.Err = fixJob(ctx, result, options.Timeout)
+				}
+
+				if options.Progress != nil {
+					progress.Lock()
+					done++
+					options.Progress(done, len(jobs), *result)
+					progress.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range jobs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// fixJob fixes the optimized CDS of a job within its timeout.
+func fixJob(ctx context.Context, result *JobResult, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	sequence, lockedProblems, err := FixSequence(ctx, result.Result.Optimized, result.Job.CodonTable, result.Job.Options)
+	result.Duration = time.Since(start)
+	if err != nil {
+		return err
+	}
+	result.Result.Sequence = sequence
+	result.Result.LockedProblems = lockedProblems
+	return nil
+}
@@ -0,0 +1,36 @@
+package design
+
+import "sort"
+
+// Problem is a problem FixSequence removes, found in a CDS. Start and End are
+// nucleotide positions, End not included, of the codons it covers.
+type Problem struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Type  string `json:"type"`
+}
+
+// FindProblems looks for the problems FixSequence would remove in a CDS, e.g.
+// to report what is left in a designed sequence or to check one made
+// elsewhere.
+func FindProblems(sequence string, options Options) []Problem {
+	found := make(map[Problem]bool)
+	var problems []Problem
+	for _, suggestion := range findSuggestions(sequence, FixFunctions(options)) {
+		problem := Problem{Start: suggestion.Start * 3, End: suggestion.End*3 + 3, Type: suggestion.SuggestionType}
+		if !found[problem] {
+			found[problem] = true
+			problems = append(problems, problem)
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool {
+		if problems[i].Start != problems[j].Start {
+			return problems[i].Start < problems[j].Start
+		}
+		if problems[i].End != problems[j].End {
+			return problems[i].End < problems[j].End
+		}
+		return problems[i].Type < problems[j].Type
+	})
+	return problems
+}
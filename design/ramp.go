@@ -0,0 +1,184 @@
+package design
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/Open-Science-Global/poly/checks"
+	"github.com/Open-Science-Global/poly/transform/codon"
+)
+
+// Defaults of Ramp.
+const (
+	DefaultRampCodons     = 40
+	DefaultRampCandidates = 20
+)
+
+// Ramp is a 5' codon ramp: the first codons of a CDS are picked from their
+// own codon table, of rarer and AT rich codons, so ribosomes start slowly and
+// don't queue up, and the mRNA around the start folds as little as possible.
+// The rest of the CDS is optimized with the codon table of the design.
+type Ramp struct {
+	// Codons is how many codons the ramp is, DefaultRampCodons when 0.
+	Codons int `json:"codons"`
+	// Table is the codon table of the ramp, RampTable of the codon table of
+	// the design when it has no amino acids.
+	Table codon.Table `json:"-"`
+	// Candidates is how many ramps are made to keep the one folding the
+	// least, DefaultRampCandidates when 0.
+	Candidates int `json:"candidates"`
+}
+
+func (ramp Ramp) codons() int {
+	if ramp.Codons <= 0 {
+		return DefaultRampCodons
+	}
+	return ramp.Codons
+}
+
+func (ramp Ramp) candidates() int {
+	if ramp.Candidates <= 0 {
+		return DefaultRampCandidates
+	}
+	return ramp.Candidates
+}
+
+func (ramp Ramp) table(codonTable codon.Table) codon.Table {
+	if len(ramp.Table.AminoAcids) == 0 {
+		return RampTable(codonTable)
+	}
+	return ramp.Table
+}
+
+// Optimize makes a CDS for a protein with the ramp, see CodonOptimize. The
+// codons after the ramp are picked with the codon table.
+func (ramp Ramp) Optimize(protein string, codonTable codon.Table, random *rand.Rand) (string, error) {
+	codons := ramp.codons()
+	if codons > len(protein) {
+		codons = len(protein)
+	}
+	rampTable := ramp.table(codonTable)
+
+	best, bestScore := "", math.Inf(1)
+	for candidate := 0; candidate < ramp.candidates(); candidate++ {
+		rampSequence, err := optimizeCodons(protein[:codons], rampTable, random)
+		if err != nil {
+			return "", fmt.Errorf("could not optimize ramp: %w", err)
+		}
+		if score := FoldScore(rampSequence); score < bestScore {
+			best, bestScore = rampSequence, score
+		}
+	}
+
+	optimizedSequence := best
+	if codons < len(protein) {
+		rest, err := optimizeCodons(protein[codons:], codonTable, random)
+		if err != nil {
+			return "", fmt.Errorf("could not optimize sequence: %w", err)
+		}
+		optimizedSequence += rest
+	}
+	if err := checkTranslation(optimizedSequence, protein); err != nil {
+		return "", err
+	}
+	return optimizedSequence, nil
+}
+
+// RampTable makes the codon table of a ramp from the codon table of a design.
+// Each codon used in the table is weighted by how rare it is among its
+// synonyms, up to twice as much as the most used one, times the square of one
+// more than its number of A and T, so low GC weighs more than rarity. Codons
+// the table never uses are left out, as the host may hardly translate them at
+// all.
+func RampTable(codonTable codon.Table) codon.Table {
+	rampTable := codon.Table{StartCodons: codonTable.StartCodons, StopCodons: codonTable.StopCodons}
+	for _, aminoAcid := range codonTable.AminoAcids {
+		most := 0
+		for _, triplet := range aminoAcid.Codons {
+			if triplet.Weight > most {
+				most = triplet.Weight
+			}
+		}
+
+		rampAminoAcid := codon.AminoAcid{Letter: aminoAcid.Letter}
+		for _, triplet := range aminoAcid.Codons {
+			weight := 0
+			if triplet.Weight > 0 {
+				rarity := int(100 * (2 - float64(triplet.Weight)/float64(most)))
+				at := 1 + strings.Count(triplet.Triplet, "A") + strings.Count(triplet.Triplet, "T")
+				weight = rarity * at * at
+			}
+			rampAminoAcid.Codons = append(rampAminoAcid.Codons, codon.Codon{Triplet: triplet.Triplet, Weight: weight})
+		}
+		rampTable.AminoAcids = append(rampTable.AminoAcids, rampAminoAcid)
+	}
+	return rampTable
+}
+
+// RampScore compares the ramp of a CDS with the rest of it.
+type RampScore struct {
+	Codons int `json:"codons"`
+	// GC content of the ramp and of the rest of the CDS.
+	RampGC float64 `json:"ramp_gc"`
+	RestGC float64 `json:"rest_gc"`
+	// Adaptation of the ramp and of the rest of the CDS to the codon table of
+	// the design, see Adaptation. The ramp should be lower.
+	RampAdaptation float64 `json:"ramp_adaptation"`
+	RestAdaptation float64 `json:"rest_adaptation"`
+	// RampFold is the FoldScore of the ramp.
+	RampFold float64 `json:"ramp_fold"`
+}
+
+// Score scores the ramp at the start of a CDS made with a codon table.
+func (ramp Ramp) Score(sequence string, codonTable codon.Table) RampScore {
+	split := ramp.codons() * 3
+	if split > len(sequence) {
+		split = len(sequence) - len(sequence)%3
+	}
+	start, rest := sequence[:split], sequence[split:]
+	score := RampScore{Codons: split / 3, RampAdaptation: Adaptation(start, codonTable), RestAdaptation: Adaptation(rest, codonTable), RampFold: FoldScore(start)}
+	if len(start) > 0 {
+		score.RampGC = checks.GcContent(start)
+	}
+	if len(rest) > 0 {
+		score.RestGC = checks.GcContent(rest)
+	}
+	return score
+}
+
+// Adaptation is the geometric mean, over the codons of a CDS, of the weight
+// of each codon over the weight of the most used synonym in the codon table,
+// like the codon adaptation index. It is 1 for a CDS of only the most used
+// codons and lower for rarer ones. Stop codons and codons without weight are
+// left out.
+func Adaptation(sequence string, codonTable codon.Table) float64 {
+	adaptation := make(map[string]float64)
+	for _, aminoAcid := range codonTable.AminoAcids {
+		most := 0
+		for _, triplet := range aminoAcid.Codons {
+			if triplet.Weight > most {
+				most = triplet.Weight
+			}
+		}
+		for _, triplet := range aminoAcid.Codons {
+			if triplet.Weight > 0 && aminoAcid.Letter != "*" {
+				adaptation[triplet.Triplet] = float64(triplet.Weight) / float64(most)
+			}
+		}
+	}
+
+	sum, count := 0.0, 0
+	sequence = strings.ToUpper(sequence)
+	for position := 0; position+3 <= len(sequence); position += 3 {
+		if value, ok := adaptation[sequence[position:position+3]]; ok {
+			sum += math.Log(value)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return math.Exp(sum / float64(count))
+}
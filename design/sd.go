@@ -0,0 +1,149 @@
+package design
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/Open-Science-Global/poly/synthesis"
+	"github.com/Open-Science-Global/poly/transform"
+)
+
+// DefaultInternalSDEnergy is the free energy, in kcal/mol, under which a
+// Shine-Dalgarno like sequence before a start codon inside a CDS counts as an
+// internal start. GGAGG pairs with -6.86 and AGGAGG with -8.94.
+const DefaultInternalSDEnergy = -6.0
+
+// InternalSDMinSpacing and InternalSDMaxSpacing are the bases between the end
+// of an internal Shine-Dalgarno and its start codon for it to start
+// translation.
+const (
+	InternalSDMinSpacing = 3
+	InternalSDMaxSpacing = 12
+)
+
+// startCodons are the start codons of bacteria.
+var startCodons = []string{"ATG", "GTG", "TTG"}
+
+// stacks are the free energies, in kcal/mol, of each pair of RNA base pairs
+// stacked in a helix, by the top strand 5' to 3', from Xia et al. 1998.
+var stacks = map[string]float64{
+	"AA": -0.93, "TT": -0.93,
+	"AT": -1.10,
+	"TA": -1.33,
+	"CT": -2.08, "AG": -2.08,
+	"CA": -2.11, "TG": -2.11,
+	"GT": -2.24, "AC": -2.24,
+	"GA": -2.35, "TC": -2.35,
+	"CG": -2.36,
+	"GG": -3.26, "CC": -3.26,
+	"GC": -3.42,
+}
+
+// helixInitiation is the free energy of starting a helix, in kcal/mol.
+const helixInitiation = 4.09
+
+// HelixEnergy is the free energy, in kcal/mol, of a helix of RNA of the
+// sequence paired with its complement: the stacks of its base pairs and the
+// start of the helix.
+func HelixEnergy(sequence string) float64 {
+	sequence = strings.ToUpper(sequence)
+	energy := helixInitiation
+	for k := 0; k+1 < len(sequence); k++ {
+		energy += stacks[sequence[k:k+2]]
+	}
+	return energy
+}
+
+// PairSD finds the run of bases of a sequence pairing with an anti
+// Shine-Dalgarno, the 3' end of the 16S rRNA, without gaps or mismatches,
+// with the lowest free energy. It returns the run, end not included, and its
+// free energy in kcal/mol, or an empty run when no helix has a negative free
+// energy.
+func PairSD(sequence string, antiSD string) (int, int, float64) {
+	return pairSD(sequence, antiSD, 0)
+}
+
+// pairSD is PairSD for the runs ending at minEnd or after it.
+func pairSD(sequence string, antiSD string, minEnd int) (int, int, float64) {
+	sequence = strings.ToUpper(sequence)
+	target := transform.ReverseComplement(strings.ToUpper(antiSD))
+	bestStart, bestEnd, bestEnergy := 0, 0, 0.0
+	for offset := -len(target) + 1; offset < len(sequence); offset++ {
+		runStart := -1
+		for i := 0; i <= len(sequence); i++ {
+			j := i - offset
+			paired := i < len(sequence) && j >= 0 && j < len(target) && sequence[i] == target[j]
+			if paired && runStart < 0 {
+				runStart = i
+			}
+			if !paired && runStart >= 0 {
+				energy := HelixEnergy(sequence[runStart:i])
+				if i-runStart >= 2 && i >= minEnd && energy < bestEnergy {
+					bestStart, bestEnd, bestEnergy = runStart, i, energy
+				}
+				runStart = -1
+			}
+		}
+	}
+	return bestStart, bestEnd, bestEnergy
+}
+
+// InternalStart is a start codon inside a CDS with a Shine-Dalgarno like
+// sequence before it, where ribosomes may start translating a shorter or
+// frameshifted protein.
+type InternalStart struct {
+	// SDStart and SDEnd are the Shine-Dalgarno, End not included, and Energy
+	// its free energy with the anti Shine-Dalgarno.
+	SDStart int
+	SDEnd   int
+	Energy  float64
+	// Start is the position of the start codon, in any frame.
+	Start int
+	Codon string
+}
+
+// InternalStarts finds the start codons of a CDS, after its own, with a
+// sequence pairing with the anti Shine-Dalgarno under the energy, in kcal/mol,
+// a few bases before them.
+func InternalStarts(cds string, antiSD string, energy float64) []InternalStart {
+	cds = strings.ToUpper(cds)
+	var starts []InternalStart
+	for position := 3; position+3 <= len(cds); position++ {
+		codon := cds[position : position+3]
+		isStart := false
+		for _, startCodon := range startCodons {
+			isStart = isStart || codon == startCodon
+		}
+		if !isStart {
+			continue
+		}
+
+		// The SD must end between the spacings before the start codon, so it is at most as long as the anti SD
+		windowStart := position - InternalSDMaxSpacing - len(antiSD)
+		if windowStart < 0 {
+			windowStart = 0
+		}
+		windowEnd := position - InternalSDMinSpacing
+		minEnd := position - InternalSDMaxSpacing - windowStart
+		if windowEnd <= windowStart {
+			continue
+		}
+		sdStart, sdEnd, sdEnergy := pairSD(cds[windowStart:windowEnd], antiSD, minEnd)
+		if sdEnd > sdStart && sdEnergy <= energy {
+			starts = append(starts, InternalStart{SDStart: windowStart + sdStart, SDEnd: windowStart + sdEnd, Energy: sdEnergy, Start: position, Codon: codon})
+		}
+	}
+	return starts
+}
+
+// RemoveInternalSD is a problematicSequenceFunc for synthesis.FixCds that
+// suggests changing the codons of the Shine-Dalgarno of each internal start,
+// see InternalStarts.
+func RemoveInternalSD(antiSD string, energy float64) func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup) {
+	return func(sequence string, c chan synthesis.DnaSuggestion, wg *sync.WaitGroup) {
+		defer wg.Done()
+		for _, start := range InternalStarts(sequence, antiSD, energy) {
+			c <- synthesis.DnaSuggestion{Start: start.SDStart / 3, End: (start.SDEnd - 1) / 3, Bias: "NA", QuantityFixes: 1, SuggestionType: "Remove internal Shine-Dalgarno"}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package design
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInternalStartsSpacing(t *testing.T) {
+	tests := []struct {
+		spacing int
+		found   bool
+	}{
+		{2, false},
+		{InternalSDMinSpacing, true},
+		{8, true},
+		{InternalSDMaxSpacing, true},
+		{InternalSDMaxSpacing + 1, false},
+		{InternalSDMaxSpacing + 4, false},
+	}
+	for _, test := range tests {
+		cds := "ATGCCCCCCGGAGG" + strings.Repeat("C", test.spacing) + "ATGCCC"
+		starts := InternalStarts(cds, "GATCACCTCCTTA", DefaultInternalSDEnergy)
+		if found := len(starts) > 0; found != test.found {
+			t.Errorf("spacing %d: found %v internal starts, want %v", test.spacing, starts, test.found)
+			continue
+		}
+		if test.found && starts[0].Start-starts[0].SDEnd != test.spacing {
+			t.Errorf("spacing %d: found a spacing of %d", test.spacing, starts[0].Start-starts[0].SDEnd)
+		}
+	}
+}
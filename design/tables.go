@@ -0,0 +1,59 @@
+package design
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Open-Science-Global/poly/io/fasta"
+	"github.com/Open-Science-Global/poly/transform/codon"
+)
+
+// CompromiseCutOff is the share of the codons of an amino acid, in either
+// organism, under which a codon is left out of a compromise table, see
+// codon.CompromiseCodonTable.
+const CompromiseCutOff = 0.1
+
+// TableSource is how a codon table is made: from the CDSs of an organism, in
+// FASTA files, and for CDSs expressed in two organisms, as a compromise with
+// the table of the CDSs of the second one.
+type TableSource struct {
+	CDSs           []string `json:"cdss"`
+	CompromiseCDSs []string `json:"compromise_cdss"`
+}
+
+// Build makes the codon table of the source.
+func (source TableSource) Build() (codon.Table, error) {
+	if len(source.CDSs) == 0 {
+		return codon.Table{}, errors.New("a codon table needs CDSs to be made from")
+	}
+	table, err := ReadCDSTable(source.CDSs)
+	if err != nil || len(source.CompromiseCDSs) == 0 {
+		return table, err
+	}
+	second, err := ReadCDSTable(source.CompromiseCDSs)
+	if err != nil {
+		return codon.Table{}, err
+	}
+	return codon.CompromiseCodonTable(table, second, CompromiseCutOff)
+}
+
+// ReadCDSTable makes a codon table from the CDSs of FASTA files, see
+// CodonTableFromCDSs.
+func ReadCDSTable(paths []string) (codon.Table, error) {
+	var cdss []string
+	for _, path := range paths {
+		// fasta.Read doesn't return its errors
+		if _, err := os.Stat(path); err != nil {
+			return codon.Table{}, err
+		}
+		records := fasta.Read(path)
+		if len(records) == 0 {
+			return codon.Table{}, fmt.Errorf("no CDS in %s", path)
+		}
+		for _, record := range records {
+			cdss = append(cdss, record.Sequence)
+		}
+	}
+	return CodonTableFromCDSs(cdss), nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/goldengate"
+)
+
+func main() {
+	// Picks the overhangs for a new assembly instead of choosing them by hand, e.g. when adding part positions
+	// between the GGAG and CGCT overhangs used by createCdsPart in overhangs.go
+	parts := flag.Int("parts", 2, "number of parts in the assembly")
+	fixed := flag.String("fixed", "GGAG,CGCT", "comma separated overhangs that must be in the set")
+	exclude := flag.String("exclude", "", "comma separated overhangs that must not be used")
+	matrixPath := flag.String("matrix", "../data/ligation/T4_01h_25C.csv", "ligation frequency matrix")
+	flag.Parse()
+
+	ligationMatrix, err := goldengate.ReadLigationMatrix(*matrixPath)
+	if err != nil {
+		fmt.Println("Could not read the ligation frequency matrix, see ../data/ligation/README.md:", err)
+		os.Exit(1)
+	}
+
+	report, err := ligationMatrix.DesignOverhangs(*parts, splitOverhangs(*fixed), splitOverhangs(*exclude))
+	if err != nil {
+		fmt.Println("Could not design an overhang set:", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Overhangs:", strings.Join(report.Overhangs, " "))
+	fmt.Printf("Predicted fidelity: %.3f\n", report.Fidelity)
+	for _, overhang := range report.PerOverhang {
+		fmt.Printf("%s %.3f %s\n", overhang.Overhang, overhang.Fidelity, overhang.WorstPartner)
+	}
+}
+
+func splitOverhangs(list string) []string {
+	var overhangs []string
+	for _, overhang := range strings.Split(list, ",") {
+		if overhang = strings.TrimSpace(overhang); overhang != "" {
+			overhangs = append(overhangs, overhang)
+		}
+	}
+	return overhangs
+}
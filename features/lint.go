@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/host"
+	"github.com/Open-Science-Global/friendzymes_toolkit/problems"
+)
+
+// Exit statuses of lint, so scripts can tell parts that must not be ordered
+// from a lint that could not run.
+const (
+	lintPassed  = 0
+	lintBlocked = 1
+	lintFailed  = 2
+)
+
+// lintReport is the json output of lint.
+type lintReport struct {
+	Profile string     `json:"profile"`
+	Passed  bool       `json:"passed"`
+	Parts   []lintPart `json:"parts"`
+}
+
+type lintPart struct {
+	File     string            `json:"file"`
+	Sequence string            `json:"sequence"`
+	Passed   bool              `json:"passed"`
+	Blocking int               `json:"blocking"`
+	Problems []problems.Record `json:"problems"`
+}
+
+func main() {
+	hostName := flag.String("host", host.Default, "host profile in ../data/hosts, or the path of a host directory")
+	profileName := flag.String("profile", "", "rule profile in ../data/profiles, or the path of a profile json file, the rules of the host when empty")
+	expect := flag.String("expect", "", "comma separated sites every part is meant to have, as enzyme:count, e.g. BsaI:2,BbsI:2")
+	format := flag.String("format", "text", "output format: text or json")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: go run lint.go [flags] directory or glob of GenBank and FASTA files...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 || (*format != "text" && *format != "json") {
+		flag.Usage()
+		os.Exit(lintFailed)
+	}
+
+	hostProfile, err := host.Read("../data/hosts", *hostName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not read the host profile:", err)
+		os.Exit(lintFailed)
+	}
+	profilePath := hostProfile.Rules
+	if *profileName != "" {
+		profilePath = *profileName
+		if !strings.HasSuffix(profilePath, ".json") {
+			profilePath = "../data/profiles/" + profilePath + ".json"
+		}
+	}
+	profile, err := problems.ReadProfile(profilePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not read the rule profile:", err)
+		os.Exit(lintFailed)
+	}
+	// Host homology is always checked against the genome of the host
+	profile.Genome = hostProfile.Genome
+	rules, err := profile.BuildRules()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not make the rules of the profile:", err)
+		os.Exit(lintFailed)
+	}
+	expectations, err := problems.ParseExpectations(*expect)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not read -expect:", err)
+		os.Exit(lintFailed)
+	}
+
+	var parts []problems.Part
+	for _, input := range flag.Args() {
+		inputParts, err := problems.ReadParts(input)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Could not list", input+":", err)
+			os.Exit(lintFailed)
+		}
+		parts = append(parts, inputParts...)
+	}
+	if len(parts) == 0 {
+		fmt.Fprintln(os.Stderr, "No GenBank or FASTA files in", strings.Join(flag.Args(), " "))
+		os.Exit(lintFailed)
+	}
+
+	report := lintReport{Profile: profile.Name, Passed: true}
+	for _, part := range parts {
+		found := problems.FindSequence(part.Sequence, rules)
+		partExpectations := append(append([]problems.Expectation{}, expectations...), problems.FromFeatures(part.Sequence)...)
+		_, unexpected, err := problems.Split(part.Sequence.Sequence, found, partExpectations)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Could not sort problems of", part.File+":", err)
+			os.Exit(lintFailed)
+		}
+
+		blocking := len(problems.Blocking(unexpected))
+		report.Parts = append(report.Parts, lintPart{
+			File:     part.File,
+			Sequence: part.Sequence.Meta.Locus.Name,
+			Passed:   blocking == 0,
+			Blocking: blocking,
+			Problems: problems.Records(unexpected),
+		})
+		if blocking > 0 {
+			report.Passed = false
+		}
+	}
+
+	if *format == "json" {
+		output, err := json.MarshalIndent(report, "", " ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Could not write the report:", err)
+			os.Exit(lintFailed)
+		}
+		fmt.Println(string(output))
+	} else {
+		// One line per problem, like compilers and other linters
+		for _, part := range report.Parts {
+			for _, problem := range part.Problems {
+				fmt.Printf("%s:%s:%d-%d: %s %s: %s\n", part.File, part.Sequence, problem.Start, problem.End, problem.Severity, problem.Rule, problem.Message)
+			}
+		}
+	}
+
+	if !report.Passed {
+		os.Exit(lintBlocked)
+	}
+	os.Exit(lintPassed)
+}
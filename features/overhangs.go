@@ -1,11 +1,15 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"math/rand"
+	"os"
+	"strings"
 	"time"
 
-	"github.com/Open-Science-Global/poly/finder"
+	"github.com/Open-Science-Global/friendzymes_toolkit/goldengate"
+	"github.com/Open-Science-Global/friendzymes_toolkit/manifest"
 	"github.com/Open-Science-Global/poly/io/fasta"
 )
 
@@ -15,107 +19,104 @@ type Position struct {
 }
 
 func main() {
-	enzymes := fasta.Read("../data/output/output_manually.fasta")
-	rand.Seed(time.Now().UnixNano())
-	var fastas []fasta.Fasta
-	for _, enzyme := range enzymes {
-		updated := fasta.Fasta{enzyme.Name, createCdsRemoveProblems(enzyme.Sequence)}
-		fastas = append(fastas, updated)
+	// The part standard decides the enzymes, overhangs and random bases around each part
+	standardName := flag.String("standard", "friendzymes", "part standard in ../data/standards")
+	partType := flag.String("type", "cds", "part type in the standard: promoter, rbs, cds, terminator...")
+	allowed := flag.String("allow", "", "comma separated forbidden sites that the sequences are meant to have")
+	seed := flag.Int64("seed", 0, "seed for the random flanks, a new one is picked and recorded in the manifest when 0")
+	flag.Parse()
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	fmt.Println("Seed:", *seed)
+	random := rand.New(rand.NewSource(*seed))
+
+	standardPath := "../data/standards/" + *standardName + ".json"
+	standard, err := goldengate.ReadStandard(standardPath)
+	if err != nil {
+		fmt.Println("Could not read the part standard:", err)
+		os.Exit(1)
 	}
 
-	fasta.Write(fastas, "../data/output/outputWithOverhangs.fasta")
-}
-
-// 15 random bp -> bbsi cut site forward GAAGAC -> 2bp -> bbsi overhang GGAG -> random 8bp ->
-// bsai site forward -> bsai overhang -> main sequence -> bsai overhang 2 -> bsai site reverse -> random 8bp ->
-// bbsi overhang CGCT -> 2bp -> bbsi cute site reverse GTCTTC -> 15 random bp
-func addBbsiStructureFoward(internalOverhang string) string {
-	bbsiFoward := "GAAGAC"
-	bbsiOverhangFoward := "GGAG"
-
-	randomFoward := createRandomDnaSequenceRemoveForbidden(15)
-	twoRandomFoward := createRandomDnaSequenceRemoveForbidden(2)
-	eightRandomFoward := createRandomDnaSequenceRemoveForbidden(8)
-
-	return randomFoward + bbsiFoward + twoRandomFoward + bbsiOverhangFoward + eightRandomFoward + internalOverhang
-
-}
-
-func createCdsRemoveProblems(sequence string) string {
-
-	var functions []func(string) []finder.Match
-	functions = append(functions, finder.ForbiddenSequence(restrictionBindingSitesListOverhangs()))
+	enzymesPath := "../data/output/output_manually.fasta"
+	enzymes := fasta.Read(enzymesPath)
 
-	originalProblems := finder.Find(sequence, functions)
-	fmt.Println("Original problems:", originalProblems)
-	check := true
-	var cds string
-	for check {
-		cds = createCdsPart(sequence)
-		var functions []func(string) []finder.Match
-		functions = append(functions, finder.ForbiddenSequence(restrictionBindingSitesListOverhangs()))
+	// Ligation frequencies used to predict if the overhangs of each part will assemble correctly
+	ligationMatrixPath := "../data/ligation/T4_01h_25C.csv"
+	ligationMatrix, err := goldengate.ReadLigationMatrix(ligationMatrixPath)
+	if err != nil {
+		fmt.Println("Could not read the ligation frequency matrix, see ../data/ligation/README.md:", err)
+		os.Exit(1)
+	}
+	checkOverhangs(ligationMatrix, standard)
 
-		problems := finder.Find(cds, functions)
-		if len(problems) < len(originalProblems)+8 {
-			break
+	var fastas []fasta.Fasta
+	for _, enzyme := range enzymes {
+		part, err := createCdsRemoveProblems(standard, *partType, enzyme.Sequence, strings.Split(*allowed, ","), random)
+		if err != nil {
+			fmt.Println("Could not create part for", enzyme.Name+":", err)
+			os.Exit(1)
 		}
+		fastas = append(fastas, fasta.Fasta{Name: enzyme.Name, Sequence: part})
 	}
 
-	problems := finder.Find(cds, functions)
-	fmt.Println("New problems:", problems)
-	return cds
-}
-
-func createRandomDnaSequenceRemoveForbidden(size int) string {
-
-	check := true
-
-	var randomSequence string
-	for check {
-		randomSequence = randomDnaSequence(size)
-		var functions []func(string) []finder.Match
-		functions = append(functions, finder.ForbiddenSequence(restrictionBindingSitesListOverhangs()))
-
-		problems := finder.Find(randomSequence, functions)
-
-		if len(problems) == 0 {
-			break
+	outputPath := "../data/output/outputWithOverhangs.fasta"
+	fasta.Write(fastas, outputPath)
+
+	// Everything needed to make the same parts again: run with -seed and the parameters recorded here
+	runManifest := manifest.New("overhangs", *seed)
+	flag.VisitAll(func(f *flag.Flag) {
+		runManifest.SetParameter(f.Name, f.Value.String())
+	})
+	for _, path := range []string{standardPath, enzymesPath, ligationMatrixPath} {
+		if err := runManifest.AddInput(path); err != nil {
+			fmt.Println("Could not add input to the manifest:", err)
 		}
 	}
-	return randomSequence
-}
-
-func addBbsiStructureReverse(internalOverhang string) string {
-	bbsiReverse := "GTCTTC"
-	bbsiOverhangReverse := "CGCT"
-	randomReverse := createRandomDnaSequenceRemoveForbidden(15)
-	twoRandomReverse := createRandomDnaSequenceRemoveForbidden(2)
-	eightRandomReverse := createRandomDnaSequenceRemoveForbidden(8)
-
-	return internalOverhang + eightRandomReverse + bbsiOverhangReverse + twoRandomReverse + bbsiReverse + randomReverse
-
+	if err := runManifest.AddOutput(outputPath); err != nil {
+		fmt.Println("Could not add output to the manifest:", err)
+	}
+	if err := runManifest.Write("../data/output/outputWithOverhangs.manifest.json"); err != nil {
+		fmt.Println("Could not write the manifest:", err)
+		os.Exit(1)
+	}
 }
 
-func createCdsPart(sequence string) string {
-	spacer := "T"
-	bsaiFoward := "GGTCTC" + spacer
-	bsaiReverse := spacer + "GAGACC"
-	fiveOverhang := bsaiFoward + "A"
-	threeOverhang := "GCTT" + bsaiReverse
+// createCdsRemoveProblems makes the part and checks it as a whole, so problems made where the flanks meet the sites,
+// overhangs and sequence are also found.
+func createCdsRemoveProblems(standard goldengate.Standard, partType string, sequence string, allowed []string, random *rand.Rand) (string, error) {
+	options := goldengate.FlankOptions{
+		Forbidden:   restrictionBindingSitesListOverhangs(),
+		Allowed:     allowed,
+		MaxAttempts: goldengate.DefaultMaxAttempts,
+		Rand:        random,
+	}
+	part, err := goldengate.MakePart(standard, partType, sequence, options)
+	if err != nil {
+		return "", err
+	}
 
-	return addBbsiStructureFoward(fiveOverhang) + sequence + addBbsiStructureReverse(threeOverhang)
+	fmt.Println("Problems the flanks can't remove:", part.Unavoidable)
+	fmt.Println("Intentional sites:", part.Expected)
+	fmt.Println("Flanks found after", part.Attempts, "attempts")
+	return part.Sequence, nil
 }
 
-func randomDnaSequence(length int) string {
-	var dnaAlphabet = []rune("ATCG")
-
-	randomSequence := make([]rune, length)
-
-	for basepair := range randomSequence {
-		randomIndex := rand.Intn(len(dnaAlphabet))
-		randomSequence[basepair] = dnaAlphabet[randomIndex]
+// checkOverhangs scores the overhangs used by the parts of a standard. Each level is used in a different assembly,
+// so each set is scored on its own.
+func checkOverhangs(ligationMatrix goldengate.LigationMatrix, standard goldengate.Standard) {
+	for i, level := range standard.Levels {
+		report, err := ligationMatrix.SetFidelity(standard.LevelOverhangs(i))
+		if err != nil {
+			fmt.Println("Could not score", level.Enzyme, "overhangs of", standard.Name+":", err)
+			continue
+		}
+		fmt.Printf("%s %s overhangs %v predicted fidelity: %.3f\n", standard.Name, level.Enzyme, report.Overhangs, report.Fidelity)
+		for _, warning := range report.Warnings {
+			fmt.Println("Warning:", warning)
+		}
 	}
-	return string(randomSequence)
 }
 
 func restrictionBindingSitesListOverhangs() []string {
@@ -181,8 +182,6 @@ func restrictionBindingSitesListOverhangs() []string {
 		EcoRI,
 		SphI,
 		AvrII,
-		SacI,
-		SalI,
 		SwaI,
 		AscI,
 		FseI,
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/host"
+	"github.com/Open-Science-Global/friendzymes_toolkit/problems"
+	"github.com/Open-Science-Global/poly/io/genbank"
+)
+
+func main() {
+	input := flag.String("input", "../data/final_parts_260824/", "directory or glob of GenBank and FASTA files to scan")
+	output := flag.String("output", "../data/output/scan/", "directory for the annotated GenBank copies")
+	hostName := flag.String("host", host.Default, "host profile in ../data/hosts, or the path of a host directory")
+	profileName := flag.String("profile", "", "rule profile in ../data/profiles, the rules of the host when empty")
+	expect := flag.String("expect", "", "comma separated sites every part is meant to have, as enzyme:count, e.g. BsaI:2,BbsI:2")
+	export := flag.String("export", "", "comma separated formats to also write the problems in: gff, bed, json")
+	flag.Parse()
+
+	parts, err := problems.ReadParts(*input)
+	if err != nil {
+		fmt.Println("Could not list", *input+":", err)
+		os.Exit(1)
+	}
+	if len(parts) == 0 {
+		fmt.Println("No GenBank or FASTA files in", *input)
+		os.Exit(1)
+	}
+	expectations, err := problems.ParseExpectations(*expect)
+	if err != nil {
+		fmt.Println("Could not read -expect:", err)
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(*output, 0755); err != nil {
+		fmt.Println("Could not create", *output+":", err)
+		os.Exit(1)
+	}
+
+	hostProfile, err := host.Read("../data/hosts", *hostName)
+	if err != nil {
+		fmt.Println("Could not read the host profile:", err)
+		os.Exit(1)
+	}
+	profilePath := hostProfile.Rules
+	if *profileName != "" {
+		profilePath = "../data/profiles/" + *profileName + ".json"
+	}
+	profile, err := problems.ReadProfile(profilePath)
+	if err != nil {
+		fmt.Println("Could not read the rule profile:", err)
+		os.Exit(1)
+	}
+	// Host homology is always checked against the genome of the host
+	profile.Genome = hostProfile.Genome
+	rules, err := profile.BuildRules()
+	if err != nil {
+		fmt.Println("Could not make the rules of the profile:", err)
+		os.Exit(1)
+	}
+
+	categories := []problems.Category{problems.RestrictionSite, problems.Homopolymer, problems.Repeat, problems.HostHomology, problems.Hairpin}
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := []string{"file", "sequence", "expected", "blocking", "warnings"}
+	for _, category := range categories {
+		header = append(header, string(category))
+	}
+	fmt.Fprintln(table, strings.Join(header, "\t"))
+
+	blocking := 0
+	for _, part := range parts {
+		name := part.Sequence.Meta.Locus.Name
+		found := problems.FindSequence(part.Sequence, rules)
+		partExpectations := append(append([]problems.Expectation{}, expectations...), problems.FromFeatures(part.Sequence)...)
+		expected, unexpected, err := problems.Split(part.Sequence.Sequence, found, partExpectations)
+		if err != nil {
+			fmt.Println("Could not sort problems of", part.File+":", err)
+			os.Exit(1)
+		}
+
+		outputPath := filepath.Join(*output, part.Name+".gb")
+		genbank.Write(problems.Annotate(unexpected, part.Sequence), outputPath)
+		for _, format := range strings.Split(*export, ",") {
+			if format == "" {
+				continue
+			}
+			exportPath := strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + "." + format
+			if err := problems.Export(exportPath, part.Sequence, unexpected); err != nil {
+				fmt.Println("Could not export problems of", part.File+":", err)
+				os.Exit(1)
+			}
+		}
+
+		counts := make(map[problems.Category]int)
+		warnings := 0
+		for _, problem := range unexpected {
+			counts[problem.Category]++
+			if problem.Severity == problems.Warn {
+				warnings++
+			}
+		}
+		partBlocking := len(problems.Blocking(unexpected))
+		blocking += partBlocking
+
+		row := []string{filepath.Base(part.File), name, strconv.Itoa(len(expected)), strconv.Itoa(partBlocking), strconv.Itoa(warnings)}
+		for _, category := range categories {
+			row = append(row, strconv.Itoa(counts[category]))
+		}
+		fmt.Fprintln(table, strings.Join(row, "\t"))
+	}
+	table.Flush()
+
+	// Warnings are annotated for us to look at, but only blocking problems fail the run
+	if blocking > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,147 @@
+package goldengate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Open-Science-Global/poly/transform"
+)
+
+// maxDesignRounds caps how many times DesignOverhangs tries to improve a set by
+// swapping overhangs. Sets usually stop improving after a few rounds.
+const maxDesignRounds = 50
+
+// DesignOverhangs chooses the overhang set with the highest predicted fidelity
+// for an assembly of the given number of parts, which joins parts+1 overhangs
+// counting the two ends. Fixed overhangs, like our standard GGAG and CGCT, are
+// always kept in the set, and excluded overhangs (and their reverse
+// complements) are never picked, e.g. overhangs already used by another
+// assembly level. Palindromes and overhangs within one base of another one in
+// the set are never picked either, nor overhangs whose reverse complement is
+// not in the matrix. Fixed overhangs breaking these rules are an error.
+//
+// The set is built greedily, adding the overhang that keeps the highest
+// fidelity each time, and then improved by swapping picked overhangs for
+// better ones until no swap helps.
+func (matrix LigationMatrix) DesignOverhangs(parts int, fixed []string, excluded []string) (FidelityReport, error) {
+	if parts < 1 {
+		return FidelityReport{}, fmt.Errorf("an assembly needs at least one part, not %d", parts)
+	}
+	size := parts + 1
+	if len(fixed) > size {
+		return FidelityReport{}, fmt.Errorf("%d fixed overhangs don't fit in an assembly of %d parts", len(fixed), parts)
+	}
+
+	forbidden := make(map[string]bool)
+	for _, overhang := range excluded {
+		overhang = strings.ToUpper(overhang)
+		forbidden[overhang] = true
+		forbidden[transform.ReverseComplement(overhang)] = true
+	}
+
+	// Fixed overhangs follow the same rules as picked ones, or the set can't assemble
+	var set []string
+	for _, overhang := range fixed {
+		overhang = strings.ToUpper(overhang)
+		switch {
+		case !matrix.scores(overhang):
+			return FidelityReport{}, fmt.Errorf("fixed overhang %s or its reverse complement is not in the ligation matrix", overhang)
+		case overhang == transform.ReverseComplement(overhang):
+			return FidelityReport{}, fmt.Errorf("fixed overhang %s is a palindrome", overhang)
+		case forbidden[overhang]:
+			return FidelityReport{}, fmt.Errorf("fixed overhang %s is excluded", overhang)
+		case !compatibleOverhang(overhang, set, -1):
+			return FidelityReport{}, fmt.Errorf("fixed overhang %s is within one base of another fixed overhang, on either strand", overhang)
+		}
+		set = append(set, overhang)
+	}
+
+	// Sorting the candidates keeps the design the same between runs
+	var candidates []string
+	for overhang := range matrix {
+		if !forbidden[overhang] && overhang != transform.ReverseComplement(overhang) && matrix.scores(overhang) {
+			candidates = append(candidates, overhang)
+		}
+	}
+	sort.Strings(candidates)
+
+	for len(set) < size {
+		best, bestFidelity := "", -1.0
+		for _, candidate := range candidates {
+			if !compatibleOverhang(candidate, set, -1) {
+				continue
+			}
+			fidelity, err := matrix.fidelity(append(set, candidate))
+			if err != nil {
+				return FidelityReport{}, err
+			}
+			if fidelity > bestFidelity {
+				best, bestFidelity = candidate, fidelity
+			}
+		}
+		if best == "" {
+			return FidelityReport{}, fmt.Errorf("could only find %d compatible overhangs for an assembly of %d parts", len(set), parts)
+		}
+		set = append(set, best)
+	}
+
+	currentFidelity, err := matrix.fidelity(set)
+	if err != nil {
+		return FidelityReport{}, err
+	}
+	for round := 0; round < maxDesignRounds; round++ {
+		improved := false
+		for position := len(fixed); position < len(set); position++ {
+			for _, candidate := range candidates {
+				if !compatibleOverhang(candidate, set, position) {
+					continue
+				}
+				swapped := append([]string(nil), set...)
+				swapped[position] = candidate
+				fidelity, err := matrix.fidelity(swapped)
+				if err != nil {
+					return FidelityReport{}, err
+				}
+				if fidelity > currentFidelity {
+					set, currentFidelity = swapped, fidelity
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+
+	return matrix.SetFidelity(set)
+}
+
+// fidelity is SetFidelity without the report.
+func (matrix LigationMatrix) fidelity(overhangs []string) (float64, error) {
+	report, err := matrix.SetFidelity(overhangs)
+	return report.Fidelity, err
+}
+
+// scores checks that both strands of an overhang are in the matrix, so the
+// sets it is in can be scored.
+func (matrix LigationMatrix) scores(overhang string) bool {
+	_, forward := matrix[overhang]
+	_, reverse := matrix[transform.ReverseComplement(overhang)]
+	return forward && reverse
+}
+
+// compatibleOverhang checks that a candidate differs by at least two bases from
+// every overhang in the set, on both strands, ignoring the overhang at the
+// position it would replace (-1 to compare against all of them).
+func compatibleOverhang(candidate string, set []string, replacing int) bool {
+	for i, overhang := range set {
+		if i == replacing {
+			continue
+		}
+		if hammingDistance(candidate, overhang) < 2 || hammingDistance(candidate, transform.ReverseComplement(overhang)) < 2 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package goldengate
+
+import "fmt"
+
+// Enzyme is a type IIS restriction enzyme used in Golden Gate assemblies.
+type Enzyme struct {
+	Name string
+	// Site is the recognition site on the top strand, 5' to 3'.
+	Site string
+	// Cut is how many bases after the site the top strand is cut.
+	Cut int
+	// OverhangLength is the length of the 5' overhang left by the cut.
+	OverhangLength int
+}
+
+// Enzymes are the type IIS enzymes we use in part standards, by name.
+var Enzymes = map[string]Enzyme{
+	"BsaI":  {Name: "BsaI", Site: "GGTCTC", Cut: 1, OverhangLength: 4},  //5" GGTCTC N|      3"
+	"BbsI":  {Name: "BbsI", Site: "GAAGAC", Cut: 2, OverhangLength: 4},  //5" GAAGAC NN|     3"
+	"BsmBI": {Name: "BsmBI", Site: "CGTCTC", Cut: 1, OverhangLength: 4}, //5" CGTCTC N|      3"
+	"SapI":  {Name: "SapI", Site: "GCTCTTC", Cut: 1, OverhangLength: 3}, //5" GCTCTTC N|     3"
+	"BtgZI": {Name: "BtgZI", Site: "GCGATG", Cut: 10, OverhangLength: 4},
+	"AarI":  {Name: "AarI", Site: "CACCTGC", Cut: 4, OverhangLength: 4}, //5" CACCTGC N NNN| 3"
+}
+
+// GetEnzyme returns the enzyme with the given name.
+func GetEnzyme(name string) (Enzyme, error) {
+	enzyme, ok := Enzymes[name]
+	if !ok {
+		return Enzyme{}, fmt.Errorf("unknown enzyme %s", name)
+	}
+	return enzyme, nil
+}
@@ -0,0 +1,213 @@
+// Package goldengate holds the helpers we use to turn optimized CDSs into
+// Golden Gate parts: overhang fidelity scoring and the building blocks of the
+// part layout.
+package goldengate
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Open-Science-Global/poly/transform"
+)
+
+// LigationMatrix holds how many times each overhang was seen ligated to each
+// other overhang, like the T4 ligase data sets from Potapov et al. 2018
+// (https://doi.org/10.1021/acssynbio.8b00333). The first key is the overhang
+// on one fragment and the second the overhang it was ligated to, so correct
+// ligations sit at matrix[overhang][reverse complement of overhang].
+type LigationMatrix map[string]map[string]float64
+
+// OverhangFidelity is the chance that an overhang ligates to its correct
+// partner instead of any other overhang in the same set.
+type OverhangFidelity struct {
+	Overhang string
+	Fidelity float64
+	// WorstPartner is the wrong overhang with the highest ligation count, empty
+	// if the overhang never mis-ligates inside the set.
+	WorstPartner string
+}
+
+// FidelityReport is the result of scoring an overhang set.
+type FidelityReport struct {
+	Overhangs []string
+	// Fidelity is the predicted fraction of assemblies with only correct
+	// junctions, the product of the fidelity of every overhang in the set.
+	Fidelity    float64
+	PerOverhang []OverhangFidelity
+	// Warnings lists palindromic overhangs and pairs that are identical or
+	// differ by a single base, in either orientation.
+	Warnings []string
+}
+
+// ReadLigationMatrix reads a ligation frequency matrix from a csv file.
+func ReadLigationMatrix(path string) (LigationMatrix, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseLigationMatrix(file)
+}
+
+// ParseLigationMatrix parses a ligation frequency matrix in csv format. The
+// header row lists the overhangs of the columns after an empty (or label)
+// cell, and each following row starts with its overhang and then the counts.
+func ParseLigationMatrix(r io.Reader) (LigationMatrix, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, errors.New("ligation matrix needs a header row and at least one overhang row")
+	}
+
+	header := records[0]
+	columns := make([]string, len(header))
+	for i := 1; i < len(header); i++ {
+		columns[i] = strings.ToUpper(strings.TrimSpace(header[i]))
+		if !isDna(columns[i]) {
+			return nil, fmt.Errorf("invalid overhang %q in ligation matrix header", header[i])
+		}
+	}
+
+	matrix := make(LigationMatrix)
+	for line, record := range records[1:] {
+		overhang := strings.ToUpper(strings.TrimSpace(record[0]))
+		if !isDna(overhang) {
+			return nil, fmt.Errorf("invalid overhang %q in ligation matrix line %d", record[0], line+2)
+		}
+		row := make(map[string]float64)
+		for i := 1; i < len(record); i++ {
+			value := strings.TrimSpace(record[i])
+			if value == "" {
+				continue
+			}
+			count, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid count %q for %s/%s in ligation matrix: %v", value, overhang, columns[i], err)
+			}
+			row[columns[i]] = count
+		}
+		matrix[overhang] = row
+	}
+	return matrix, nil
+}
+
+// SetFidelity scores a set of overhangs that will be used together in the
+// same assembly. Every overhang and its reverse complement can meet any other
+// overhang of the set in the reaction, so the fidelity of an overhang is its
+// correct ligation count over the counts against all overhangs of the set.
+func (matrix LigationMatrix) SetFidelity(overhangs []string) (FidelityReport, error) {
+	report := FidelityReport{Fidelity: 1}
+	for _, overhang := range overhangs {
+		report.Overhangs = append(report.Overhangs, strings.ToUpper(overhang))
+	}
+	report.Warnings = overhangWarnings(report.Overhangs)
+
+	// Both strands of every junction are in the reaction
+	var present []string
+	seen := make(map[string]bool)
+	for _, overhang := range report.Overhangs {
+		for _, strand := range []string{overhang, transform.ReverseComplement(overhang)} {
+			if !seen[strand] {
+				seen[strand] = true
+				present = append(present, strand)
+			}
+		}
+	}
+
+	for _, overhang := range present {
+		row, ok := matrix[overhang]
+		if !ok {
+			return report, fmt.Errorf("overhang %s is not in the ligation matrix", overhang)
+		}
+		partner := transform.ReverseComplement(overhang)
+		correct := row[partner]
+
+		total := 0.0
+		worstPartner := ""
+		worstCount := 0.0
+		for _, other := range present {
+			count := row[other]
+			total += count
+			if other != partner && count > worstCount {
+				worstCount = count
+				worstPartner = other
+			}
+		}
+
+		fidelity := 0.0
+		if total > 0 {
+			fidelity = correct / total
+		}
+		report.Fidelity *= fidelity
+		report.PerOverhang = append(report.PerOverhang, OverhangFidelity{Overhang: overhang, Fidelity: fidelity, WorstPartner: worstPartner})
+	}
+
+	sort.SliceStable(report.PerOverhang, func(i, j int) bool {
+		return report.PerOverhang[i].Fidelity < report.PerOverhang[j].Fidelity
+	})
+	return report, nil
+}
+
+// overhangWarnings flags overhangs that can't be told apart by the ligase:
+// palindromes, which ligate to themselves, and pairs of overhangs which are the
+// same or differ by only one base when read on either strand.
+func overhangWarnings(overhangs []string) []string {
+	var warnings []string
+	for i, overhang := range overhangs {
+		if overhang == transform.ReverseComplement(overhang) {
+			warnings = append(warnings, "Overhang "+overhang+" is palindromic and can ligate to itself")
+		}
+		for _, other := range overhangs[i+1:] {
+			forward := hammingDistance(overhang, other)
+			reverse := hammingDistance(overhang, transform.ReverseComplement(other))
+			switch {
+			case forward == 0:
+				warnings = append(warnings, "Overhang "+overhang+" is used twice")
+			case reverse == 0:
+				warnings = append(warnings, "Overhangs "+overhang+" and "+other+" are reverse complements of each other")
+			case forward == 1:
+				warnings = append(warnings, "Overhangs "+overhang+" and "+other+" differ by a single base")
+			case reverse == 1:
+				warnings = append(warnings, "Overhang "+overhang+" differs by a single base from the reverse complement of "+other)
+			}
+		}
+	}
+	return warnings
+}
+
+// hammingDistance counts mismatched positions between two sequences, counting
+// any length difference as mismatches.
+func hammingDistance(first string, second string) int {
+	if len(first) > len(second) {
+		first, second = second, first
+	}
+	distance := len(second) - len(first)
+	for i := range first {
+		if first[i] != second[i] {
+			distance++
+		}
+	}
+	return distance
+}
+
+func isDna(sequence string) bool {
+	if sequence == "" {
+		return false
+	}
+	for _, base := range sequence {
+		if !strings.ContainsRune("ATCG", base) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package goldengate
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const testMatrix = `,GGAG,CTCC,CGCT,AGCG,GATC
+GGAG,0,90,0,10,0
+CTCC,100,0,0,0,0
+CGCT,20,0,0,80,0
+AGCG,0,0,100,0,0
+GATC,0,0,0,0,100
+`
+
+func TestSetFidelity(t *testing.T) {
+	matrix, err := ParseLigationMatrix(strings.NewReader(testMatrix))
+	if err != nil {
+		t.Fatalf("could not parse the matrix: %v", err)
+	}
+	tests := []struct {
+		overhangs []string
+		fidelity  float64
+		worst     string
+		warnings  int
+		err       bool
+	}{
+		{[]string{"GGAG"}, 1, "GGAG", 0, false},
+		{[]string{"GGAG", "CGCT"}, 0.72, "CGCT", 0, false},
+		{[]string{"ggag", "cgct"}, 0.72, "CGCT", 0, false},
+		{[]string{"GATC"}, 1, "GATC", 1, false},
+		{[]string{"GGAG", "AATG"}, 0, "", 0, true},
+	}
+	for _, test := range tests {
+		report, err := matrix.SetFidelity(test.overhangs)
+		if (err != nil) != test.err {
+			t.Errorf("%v: got error %v, want an error: %v", test.overhangs, err, test.err)
+			continue
+		}
+		if test.err {
+			continue
+		}
+		if math.Abs(report.Fidelity-test.fidelity) > 1e-9 {
+			t.Errorf("%v: fidelity %f, want %f", test.overhangs, report.Fidelity, test.fidelity)
+		}
+		// The least faithful overhang comes first
+		if report.PerOverhang[0].Overhang != test.worst {
+			t.Errorf("%v: least faithful overhang %s, want %s", test.overhangs, report.PerOverhang[0].Overhang, test.worst)
+		}
+		if len(report.Warnings) != test.warnings {
+			t.Errorf("%v: warnings %v, want %d", test.overhangs, report.Warnings, test.warnings)
+		}
+	}
+}
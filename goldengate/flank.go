@@ -0,0 +1,283 @@
+package goldengate
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/Open-Science-Global/poly/transform"
+)
+
+// DefaultMaxAttempts is how many random sequences are tried for a flank, and
+// how many flank sets are tried for a whole part, before giving up.
+const DefaultMaxAttempts = 1000
+
+// DefaultHomopolymer is the shortest run of a single base that flanks must not
+// make.
+const DefaultHomopolymer = 6
+
+// ErrNoValidFlank is returned when no random flank without forbidden sites was
+// found within the maximum number of attempts.
+var ErrNoValidFlank = errors.New("no valid flank found")
+
+// Site is an occurrence of a forbidden sequence in a part, on either strand.
+type Site struct {
+	Sequence string
+	Start    int
+	End      int
+	Reverse  bool
+}
+
+func (site Site) String() string {
+	strand := "+"
+	if site.Reverse {
+		strand = "-"
+	}
+	return fmt.Sprintf("%s at %d-%d (%s)", site.Sequence, site.Start, site.End, strand)
+}
+
+// Part is a part made by MakePart.
+type Part struct {
+	Sequence string
+	PartCheck
+	// Attempts is how many flank sets were made until one had no unexpected
+	// site.
+	Attempts int
+}
+
+// PartCheck is an account of every forbidden site in a part.
+type PartCheck struct {
+	// Expected are the enzyme sites of the standard and the allowed sites of
+	// the insert, which the part is meant to have.
+	Expected []Site
+	// Unavoidable are forbidden sites without any random base, like the ones
+	// already in the insert or made where it meets the overhangs. Flanks can't
+	// remove them.
+	Unavoidable []Site
+	// Unexpected are forbidden sites with at least one random base.
+	Unexpected []Site
+}
+
+// FlankOptions sets what flanks must avoid and how hard to look for them.
+type FlankOptions struct {
+	// Forbidden sequences must not be made by the flanks, on either strand.
+	Forbidden []string
+	// Allowed are forbidden sequences that the insert is meant to have, like
+	// the BsmBI sites of a receiver.
+	Allowed []string
+	// MaxAttempts defaults to DefaultMaxAttempts.
+	MaxAttempts int
+	// Homopolymer is the shortest run of a single base that is forbidden like
+	// the Forbidden sequences, DefaultHomopolymer when 0 and none when
+	// negative.
+	Homopolymer int
+	// Rand makes the random bases. Parts made with sources seeded alike are
+	// the same, the shared math/rand source is used when it is nil.
+	Rand *rand.Rand
+}
+
+func (options FlankOptions) maxAttempts() int {
+	if options.MaxAttempts <= 0 {
+		return DefaultMaxAttempts
+	}
+	return options.MaxAttempts
+}
+
+// forbidden are the Forbidden sequences and the homopolymers of the options,
+// each once. FindSites looks on both strands, so a sequence listed twice or
+// with its reverse complement would report the same sites twice.
+func (options FlankOptions) forbidden() []string {
+	sites := append([]string{}, options.Forbidden...)
+	length := options.Homopolymer
+	if length == 0 {
+		length = DefaultHomopolymer
+	}
+	if length > 0 {
+		// Runs of T and G are found as runs of A and C on the other strand
+		for _, base := range "AC" {
+			sites = append(sites, strings.Repeat(string(base), length))
+		}
+	}
+
+	var forbidden []string
+	found := make(map[string]bool)
+	for _, site := range sites {
+		site = strings.ToUpper(site)
+		if !found[site] {
+			found[site] = true
+			found[transform.ReverseComplement(site)] = true
+			forbidden = append(forbidden, site)
+		}
+	}
+	return forbidden
+}
+
+// MakePart lays out a part of the standard around the insert and fills its
+// random segments, from 5' to 3', so that no forbidden site or homopolymer has
+// a random base. Each flank is checked together with the bases around it, so
+// sites and homopolymers made across a junction are avoided too. The finished
+// part is checked as a whole with CheckPart and made again when it still has
+// an unexpected site. MakePart fails with ErrNoValidFlank, listing the
+// unexpected sites of the last try, when no such part is found within the
+// maximum number of attempts.
+func MakePart(standard Standard, partType string, insert string, options FlankOptions) (Part, error) {
+	segments, err := standard.Layout(partType, insert)
+	if err != nil {
+		return Part{}, err
+	}
+
+	var check PartCheck
+	for attempt := 1; attempt <= options.maxAttempts(); attempt++ {
+		sequence, err := Assemble(segments, func(left string, length int, right string) (string, error) {
+			return RandomFlank(left, length, right, options)
+		})
+		if err != nil {
+			return Part{}, err
+		}
+
+		check = CheckPart(segments, sequence, options)
+		if len(check.Unexpected) == 0 {
+			return Part{Sequence: sequence, PartCheck: check, Attempts: attempt}, nil
+		}
+	}
+	return Part{}, fmt.Errorf("%w for %s part after %d attempts, the last one made %v", ErrNoValidFlank, partType, options.maxAttempts(), check.Unexpected)
+}
+
+// CheckPart sorts every forbidden site and homopolymer of an assembled part by
+// the segments it falls on. Sites are searched in the whole part, so those
+// spanning the junction between two segments are found as well.
+func CheckPart(segments []Segment, sequence string, options FlankOptions) PartCheck {
+	allowed := make(map[string]bool)
+	for _, site := range options.Allowed {
+		allowed[strings.ToUpper(site)] = true
+	}
+
+	var check PartCheck
+	for _, site := range FindSites(sequence, options.forbidden()) {
+		segment, inside := segmentOf(segments, site)
+		switch {
+		case touchesRandom(segments, site):
+			check.Unexpected = append(check.Unexpected, site)
+		case inside && segment.Site:
+			check.Expected = append(check.Expected, site)
+		case inside && segment.Insert && allowed[site.Sequence]:
+			check.Expected = append(check.Expected, site)
+		default:
+			check.Unavoidable = append(check.Unavoidable, site)
+		}
+	}
+	return check
+}
+
+// RandomFlank makes a random sequence of the given length that doesn't make a
+// forbidden sequence or homopolymer on either strand, either by itself or
+// together with the bases on its left and right.
+func RandomFlank(left string, length int, right string, options FlankOptions) (string, error) {
+	forbidden := options.forbidden()
+	// Only the bases that can make a forbidden sequence with the flank matter
+	context := 0
+	for _, site := range forbidden {
+		if len(site)-1 > context {
+			context = len(site) - 1
+		}
+	}
+	if len(left) > context {
+		left = left[len(left)-context:]
+	}
+	if len(right) > context {
+		right = right[:context]
+	}
+
+	for attempt := 0; attempt < options.maxAttempts(); attempt++ {
+		flank := randomDna(length, options.Rand)
+		valid := true
+		for _, site := range FindSites(left+flank+right, forbidden) {
+			if site.Start < len(left)+length && site.End > len(left) {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			return flank, nil
+		}
+	}
+	return "", fmt.Errorf("%w of %d bp between %q and %q after %d attempts", ErrNoValidFlank, length, left, right, options.maxAttempts())
+}
+
+// FindSites finds every occurrence of the forbidden sequences in a sequence,
+// on both strands.
+func FindSites(sequence string, forbidden []string) []Site {
+	sequence = strings.ToUpper(sequence)
+	var sites []Site
+	for _, site := range forbidden {
+		site = strings.ToUpper(site)
+		reverse := transform.ReverseComplement(site)
+		for _, start := range findAll(sequence, site) {
+			sites = append(sites, Site{Sequence: site, Start: start, End: start + len(site)})
+		}
+		// Palindromic sites are the same on both strands
+		if reverse == site {
+			continue
+		}
+		for _, start := range findAll(sequence, reverse) {
+			sites = append(sites, Site{Sequence: site, Start: start, End: start + len(site), Reverse: true})
+		}
+	}
+	return sites
+}
+
+// findAll returns the start of every, possibly overlapping, occurrence of word.
+func findAll(sequence string, word string) []int {
+	var starts []int
+	for offset := 0; ; {
+		index := strings.Index(sequence[offset:], word)
+		if index < 0 {
+			return starts
+		}
+		starts = append(starts, offset+index)
+		offset += index + 1
+	}
+}
+
+// touchesRandom checks if any base of the site is in a random segment.
+func touchesRandom(segments []Segment, site Site) bool {
+	position := 0
+	for _, segment := range segments {
+		segmentEnd := position + len(segment.Sequence)
+		if segment.Random && site.Start < segmentEnd && site.End > position {
+			return true
+		}
+		position = segmentEnd
+	}
+	return false
+}
+
+// segmentOf returns the segment that holds the whole site, if there is one.
+func segmentOf(segments []Segment, site Site) (Segment, bool) {
+	position := 0
+	for _, segment := range segments {
+		segmentEnd := position + len(segment.Sequence)
+		if site.Start >= position && site.End <= segmentEnd {
+			return segment, true
+		}
+		position = segmentEnd
+	}
+	return Segment{}, false
+}
+
+func randomDna(length int, random *rand.Rand) string {
+	var dnaAlphabet = []rune("ATCG")
+
+	intn := rand.Intn
+	if random != nil {
+		intn = random.Intn
+	}
+
+	randomSequence := make([]rune, length)
+
+	for basepair := range randomSequence {
+		randomSequence[basepair] = dnaAlphabet[intn(len(dnaAlphabet))]
+	}
+	return string(randomSequence)
+}
@@ -0,0 +1,229 @@
+package goldengate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/Open-Science-Global/poly/transform"
+)
+
+// Standard describes how parts are laid out for a Golden Gate standard, from
+// the outermost enzyme level to the one next to the insert:
+//
+//	flank, site, spacer, 5' overhang, padding, [inner levels], insert,
+//	[inner levels], padding, 3' overhang, spacer, reverse site, flank
+//
+// Our two-level standard puts a BbsI level around a BsaI level, MoClo and
+// CIDAR only use BsaI.
+type Standard struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Flank       int     `json:"flank"` // random bases outside the outermost sites
+	Levels      []Level `json:"levels"`
+	// Parts holds the overhangs of each part type (promoter, rbs, cds,
+	// terminator...), one pair per level in the same order as Levels.
+	Parts map[string][]Overhangs `json:"parts"`
+}
+
+// Level is one enzyme layer of a part.
+type Level struct {
+	Enzyme string `json:"enzyme"`
+	// Spacer fills the gap between the site and the cut on both sides, N
+	// stands for a random base.
+	Spacer string `json:"spacer"`
+	// Padding is the number of random bases between the overhangs of this
+	// level and the next inner level (or the insert).
+	Padding int `json:"padding"`
+}
+
+// Overhangs are the 5' and 3' overhangs of a part type in one level.
+type Overhangs struct {
+	Five  string `json:"five"`
+	Three string `json:"three"`
+	// FiveInInsert and ThreeInInsert are how many bases of the overhang come
+	// from the insert itself, like the ATG of a CDS in an AATG overhang.
+	FiveInInsert  int `json:"five_in_insert"`
+	ThreeInInsert int `json:"three_in_insert"`
+}
+
+// Segment is a piece of a part. Random segments are only N and are filled
+// with random bases when the part is made.
+type Segment struct {
+	Name     string
+	Sequence string
+	Random   bool
+	// Site marks the enzyme sites the part is meant to have, and Insert the
+	// sequence the part is made for.
+	Site   bool
+	Insert bool
+}
+
+// ReadStandard reads a part standard from a json file.
+func ReadStandard(path string) (Standard, error) {
+	var standard Standard
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return standard, err
+	}
+	if err := json.Unmarshal(file, &standard); err != nil {
+		return standard, fmt.Errorf("could not parse part standard %s: %v", path, err)
+	}
+	return standard, standard.validate()
+}
+
+func (standard Standard) validate() error {
+	if len(standard.Levels) == 0 {
+		return fmt.Errorf("part standard %s has no levels", standard.Name)
+	}
+	for _, level := range standard.Levels {
+		enzyme, err := GetEnzyme(level.Enzyme)
+		if err != nil {
+			return fmt.Errorf("part standard %s: %v", standard.Name, err)
+		}
+		if len(level.Spacer) != enzyme.Cut {
+			return fmt.Errorf("part standard %s: %s spacer must have %d bases", standard.Name, enzyme.Name, enzyme.Cut)
+		}
+	}
+	for partType, overhangs := range standard.Parts {
+		if len(overhangs) != len(standard.Levels) {
+			return fmt.Errorf("part standard %s: %s needs overhangs for %d levels", standard.Name, partType, len(standard.Levels))
+		}
+		for i, overhang := range overhangs {
+			length := Enzymes[standard.Levels[i].Enzyme].OverhangLength
+			if len(overhang.Five) != length || len(overhang.Three) != length {
+				return fmt.Errorf("part standard %s: %s overhangs must have %d bases for %s", standard.Name, partType, length, standard.Levels[i].Enzyme)
+			}
+			if overhang.FiveInInsert < 0 || overhang.FiveInInsert > length || overhang.ThreeInInsert < 0 || overhang.ThreeInInsert > length {
+				return fmt.Errorf("part standard %s: %s overhangs can have 0 to %d bases in the insert for %s", standard.Name, partType, length, standard.Levels[i].Enzyme)
+			}
+		}
+	}
+	return nil
+}
+
+// PartTypes lists the part types defined by the standard.
+func (standard Standard) PartTypes() []string {
+	var partTypes []string
+	for partType := range standard.Parts {
+		partTypes = append(partTypes, partType)
+	}
+	sort.Strings(partTypes)
+	return partTypes
+}
+
+// LevelOverhangs returns every overhang used by the parts of the standard in
+// a level, which meet each other in the same assembly.
+func (standard Standard) LevelOverhangs(level int) []string {
+	var overhangs []string
+	seen := make(map[string]bool)
+	for _, partType := range standard.PartTypes() {
+		for _, overhang := range []string{standard.Parts[partType][level].Five, standard.Parts[partType][level].Three} {
+			if !seen[overhang] {
+				seen[overhang] = true
+				overhangs = append(overhangs, overhang)
+			}
+		}
+	}
+	return overhangs
+}
+
+// Layout returns the segments of a part of the given type around an insert.
+func (standard Standard) Layout(partType string, insert string) ([]Segment, error) {
+	partOverhangs, ok := standard.Parts[partType]
+	if !ok {
+		return nil, fmt.Errorf("part standard %s has no %s parts", standard.Name, partType)
+	}
+	insert = strings.ToUpper(insert)
+
+	var five []Segment
+	three := make([][]Segment, len(standard.Levels))
+	five = append(five, randomSegment("flank", standard.Flank))
+	for i, level := range standard.Levels {
+		enzyme := Enzymes[level.Enzyme]
+		overhangs := partOverhangs[i]
+		last := i == len(standard.Levels)-1
+
+		fiveOverhang := overhangs.Five
+		threeOverhang := overhangs.Three
+		if last {
+			if !strings.HasPrefix(insert, fiveOverhang[len(fiveOverhang)-overhangs.FiveInInsert:]) {
+				return nil, fmt.Errorf("%s part must start with %s to make the %s overhang", partType, fiveOverhang[len(fiveOverhang)-overhangs.FiveInInsert:], fiveOverhang)
+			}
+			if !strings.HasSuffix(insert, threeOverhang[:overhangs.ThreeInInsert]) {
+				return nil, fmt.Errorf("%s part must end with %s to make the %s overhang", partType, threeOverhang[:overhangs.ThreeInInsert], threeOverhang)
+			}
+			fiveOverhang = fiveOverhang[:len(fiveOverhang)-overhangs.FiveInInsert]
+			threeOverhang = threeOverhang[overhangs.ThreeInInsert:]
+		}
+
+		five = append(five,
+			Segment{Name: enzyme.Name + " site", Sequence: enzyme.Site, Site: true},
+			spacerSegment(enzyme.Name+" spacer", level.Spacer),
+			Segment{Name: enzyme.Name + " overhang", Sequence: fiveOverhang},
+			randomSegment(enzyme.Name+" padding", level.Padding),
+		)
+		// The 3' side of each level, read from the insert outwards
+		three[i] = append(three[i],
+			randomSegment(enzyme.Name+" padding", level.Padding),
+			Segment{Name: enzyme.Name + " overhang", Sequence: threeOverhang},
+			spacerSegment(enzyme.Name+" spacer", level.Spacer),
+			Segment{Name: enzyme.Name + " site", Sequence: transform.ReverseComplement(enzyme.Site), Site: true},
+		)
+	}
+
+	segments := append(five, Segment{Name: partType, Sequence: insert, Insert: true})
+	for i := len(three) - 1; i >= 0; i-- {
+		segments = append(segments, three[i]...)
+	}
+	segments = append(segments, randomSegment("flank", standard.Flank))
+	return dropEmptySegments(segments), nil
+}
+
+// Assemble joins the segments of a part from 5' to 3', filling random
+// segments with the sequences made by random for their length. Random gets the
+// part built so far on the left and the fixed bases that follow on the right,
+// up to the next random segment, so it can avoid problems across junctions.
+func Assemble(segments []Segment, random func(left string, length int, right string) (string, error)) (string, error) {
+	var part strings.Builder
+	for i, segment := range segments {
+		if !segment.Random {
+			part.WriteString(segment.Sequence)
+			continue
+		}
+		var right strings.Builder
+		for _, next := range segments[i+1:] {
+			if next.Random {
+				break
+			}
+			right.WriteString(next.Sequence)
+		}
+		filling, err := random(part.String(), len(segment.Sequence), right.String())
+		if err != nil {
+			return "", fmt.Errorf("could not fill %s: %w", segment.Name, err)
+		}
+		part.WriteString(filling)
+	}
+	return part.String(), nil
+}
+
+func randomSegment(name string, length int) Segment {
+	return Segment{Name: name, Sequence: strings.Repeat("N", length), Random: true}
+}
+
+func spacerSegment(name string, spacer string) Segment {
+	spacer = strings.ToUpper(spacer)
+	return Segment{Name: name, Sequence: spacer, Random: strings.Trim(spacer, "N") == ""}
+}
+
+func dropEmptySegments(segments []Segment) []Segment {
+	var kept []Segment
+	for _, segment := range segments {
+		if segment.Sequence != "" {
+			kept = append(kept, segment)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,42 @@
+package goldengate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLayout(t *testing.T) {
+	standard, err := ReadStandard("../data/standards/friendzymes.json")
+	if err != nil {
+		t.Fatalf("could not read the standard: %v", err)
+	}
+	flank, padding := strings.Repeat("N", 15), strings.Repeat("N", 8)
+	tests := []struct {
+		partType string
+		insert   string
+		want     string
+		err      bool
+	}{
+		// The A of the AATG overhang is the only base of it outside the CDS
+		{"cds", "atgaaataa", flank + "GAAGAC" + "NN" + "GGAG" + padding + "GGTCTC" + "T" + "A" + "ATGAAATAA" + "GCTT" + "T" + "GAGACC" + padding + "CGCT" + "NN" + "GTCTTC" + flank, false},
+		{"cds", "GTGAAATAA", "", true},
+		{"terminator", "ATGAAATAA", "", true},
+	}
+	for _, test := range tests {
+		segments, err := standard.Layout(test.partType, test.insert)
+		if (err != nil) != test.err {
+			t.Errorf("%s %s: got error %v, want an error: %v", test.partType, test.insert, err, test.err)
+			continue
+		}
+		var part strings.Builder
+		for _, segment := range segments {
+			part.WriteString(segment.Sequence)
+			if segment.Random != (strings.Trim(segment.Sequence, "N") == "") {
+				t.Errorf("%s %s: segment %s is random: %v", test.partType, test.insert, segment.Name, segment.Random)
+			}
+		}
+		if part.String() != test.want {
+			t.Errorf("%s %s: layout %s, want %s", test.partType, test.insert, part.String(), test.want)
+		}
+	}
+}
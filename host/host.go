@@ -0,0 +1,280 @@
+// Package host reads host profiles: the genome, codon tables, forbidden sites
+// and problem rules of an organism we make parts for, kept together in a
+// directory with a host.json manifest. Every command takes a host by name, so
+// switching host changes all of its checks at once.
+package host
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/design"
+	"github.com/Open-Science-Global/friendzymes_toolkit/goldengate"
+	"github.com/Open-Science-Global/friendzymes_toolkit/problems"
+	"github.com/Open-Science-Global/friendzymes_toolkit/rbs"
+	"github.com/Open-Science-Global/poly"
+	"github.com/Open-Science-Global/poly/io/genbank"
+	"github.com/Open-Science-Global/poly/transform"
+	"github.com/Open-Science-Global/poly/transform/codon"
+)
+
+// Directory is where the hosts are, from the root of the repository.
+const Directory = "data/hosts"
+
+// Default is the host our parts are made for.
+const Default = "bsub-py79"
+
+// Host is a host profile. Paths in host.json are relative to the host
+// directory, and Read makes them relative to the working directory.
+type Host struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Genome is a GenBank file of the host genome, which parts must not share
+	// HostKmer bases with. Hosts without one are not checked for homology.
+	Genome   string `json:"genome"`
+	HostKmer int    `json:"host_kmer"`
+	// Strategies are the codon tables proteins are designed with for the host.
+	Strategies []Strategy `json:"strategies"`
+	// Forbidden are the sequences parts must not have, with the sites of
+	// Enzymes, both on either strand.
+	Forbidden []string `json:"forbidden"`
+	Enzymes   []string `json:"enzymes"`
+	// UTR is the 5' UTR, with the RBS, that CDSs are expressed with in the
+	// host. Designs of StartFold strategies unfold it with their first codons,
+	// see design.StartFold.
+	UTR string `json:"utr"`
+	// AntiSD is the 3' end of the 16S rRNA of the host, read from the genome
+	// when it is empty, see rbs.AntiSD.
+	AntiSD string `json:"anti_sd"`
+	// Rules is the rule profile parts are checked against, see the problems
+	// package.
+	Rules string `json:"rules"`
+	// Directory is the directory the host was read from.
+	Directory string `json:"-"`
+}
+
+// Strategy is a codon table of a host.
+type Strategy struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// CodonTable is a codon table json file, like the ones main.go writes in
+	// data/codon-table.
+	CodonTable string `json:"codon_table"`
+	// TableSource is the CDSs BuildCodonTables makes the codon table from, as
+	// "cdss" and "compromise_cdss" FASTA files. Strategies without CDSs share
+	// the table file of another strategy, or use the file as it is.
+	design.TableSource
+	// Harmonize strategies harmonize the native CDS of each enzyme for the
+	// codon table instead of optimizing its protein, see design.Harmonize.
+	Harmonize bool `json:"harmonize"`
+	// Ramp is how many codons of a 5' codon ramp start the CDSs of the
+	// strategy, none when 0. RampTable is the codon table json file of the
+	// ramp, made from the codon table when empty, see design.Ramp.
+	Ramp      int    `json:"ramp"`
+	RampTable string `json:"ramp_table"`
+	// StartFold strategies unfold the 5' end of the mRNA of their designs,
+	// the UTR of the host with the first codons, once their problems are
+	// fixed. It is off by default as it folds the start of the CDS many times.
+	StartFold bool `json:"start_fold"`
+}
+
+// DesignRamp is the ramp of the strategy, nil when it has none.
+func (strategy Strategy) DesignRamp() (*design.Ramp, error) {
+	if strategy.Ramp <= 0 {
+		return nil, nil
+	}
+	ramp := design.Ramp{Codons: strategy.Ramp}
+	if strategy.RampTable != "" {
+		// codon.ReadCodonJSON doesn't return its errors
+		if _, err := os.Stat(strategy.RampTable); err != nil {
+			return nil, fmt.Errorf("ramp codon table of strategy %s: %w", strategy.Name, err)
+		}
+		ramp.Table = codon.ReadCodonJSON(strategy.RampTable)
+	}
+	return &ramp, nil
+}
+
+// Read reads a host from hostsDirectory by name, or from the path of a host
+// directory when name has a slash in it.
+func Read(hostsDirectory string, name string) (Host, error) {
+	directory := name
+	if !strings.ContainsRune(name, '/') {
+		directory = filepath.Join(hostsDirectory, name)
+	}
+
+	var host Host
+	file, err := ioutil.ReadFile(filepath.Join(directory, "host.json"))
+	if err != nil {
+		return host, err
+	}
+	if err := json.Unmarshal(file, &host); err != nil {
+		return host, fmt.Errorf("could not parse host %s: %v", name, err)
+	}
+	host.Directory = directory
+	host.Genome = host.path(host.Genome)
+	host.Rules = host.path(host.Rules)
+	for i := range host.Strategies {
+		host.Strategies[i].CodonTable = host.path(host.Strategies[i].CodonTable)
+		host.Strategies[i].RampTable = host.path(host.Strategies[i].RampTable)
+		for _, paths := range [][]string{host.Strategies[i].CDSs, host.Strategies[i].CompromiseCDSs} {
+			for j := range paths {
+				paths[j] = host.path(paths[j])
+			}
+		}
+	}
+	if host.HostKmer <= 0 {
+		host.HostKmer = design.DefaultHostKmer
+	}
+	return host, nil
+}
+
+func (host Host) path(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(host.Directory, path)
+}
+
+// ReadGenome reads the genome of the host.
+func (host Host) ReadGenome() (poly.Sequence, error) {
+	if host.Genome == "" {
+		return poly.Sequence{}, fmt.Errorf("host %s has no genome", host.Name)
+	}
+	if _, err := os.Stat(host.Genome); err != nil {
+		return poly.Sequence{}, err
+	}
+	return genbank.Read(host.Genome), nil
+}
+
+// ReadAntiSD is the anti Shine-Dalgarno of the host, from the host profile or
+// the 16S rRNA of its genome.
+func (host Host) ReadAntiSD() (string, error) {
+	if host.AntiSD != "" {
+		return strings.ToUpper(host.AntiSD), nil
+	}
+	genome, err := host.ReadGenome()
+	if err != nil {
+		return "", err
+	}
+	return rbs.AntiSD(genome, rbs.DefaultAntiSDLength)
+}
+
+// ForbiddenSequences are the sequences parts must not have, on both strands,
+// or nil when the host doesn't list any.
+func (host Host) ForbiddenSequences() ([]string, error) {
+	sites := append([]string{}, host.Forbidden...)
+	for _, name := range host.Enzymes {
+		enzyme, err := goldengate.GetEnzyme(name)
+		if err != nil {
+			return nil, fmt.Errorf("host %s: %w", host.Name, err)
+		}
+		sites = append(sites, enzyme.Site)
+	}
+
+	var sequences []string
+	found := make(map[string]bool)
+	for _, site := range sites {
+		for _, sequence := range []string{strings.ToUpper(site), transform.ReverseComplement(strings.ToUpper(site))} {
+			if !found[sequence] {
+				found[sequence] = true
+				sequences = append(sequences, sequence)
+			}
+		}
+	}
+	return sequences, nil
+}
+
+// DesignOptions are the design options of the host: its forbidden sequences,
+// the kmers of its genome and its anti Shine-Dalgarno to remove internal
+// starts with. The StartFold of a strategy is added by DesignStartFold.
+func (host Host) DesignOptions() (design.Options, error) {
+	forbidden, err := host.ForbiddenSequences()
+	if err != nil {
+		return design.Options{}, err
+	}
+	options := design.Options{Forbidden: forbidden, HostKmer: host.HostKmer}
+	if host.Genome != "" {
+		genome, err := host.ReadGenome()
+		if err != nil {
+			return design.Options{}, err
+		}
+		options.HostKmers = design.KmerTable(host.HostKmer, genome.Sequence, genome.Meta.Locus.Circular)
+		if host.AntiSD == "" {
+			if options.AntiSD, err = rbs.AntiSD(genome, rbs.DefaultAntiSDLength); err != nil {
+				return design.Options{}, fmt.Errorf("anti Shine-Dalgarno of host %s: %w", host.Name, err)
+			}
+		}
+	}
+	if host.AntiSD != "" {
+		options.AntiSD = strings.ToUpper(host.AntiSD)
+	}
+	return options, nil
+}
+
+// DesignStartFold is how designs of a strategy unfold the 5' end of their
+// mRNA with the UTR of the host, nil when the strategy doesn't.
+func (host Host) DesignStartFold(strategy Strategy) (*design.StartFold, error) {
+	if !strategy.StartFold {
+		return nil, nil
+	}
+	if host.UTR == "" {
+		return nil, fmt.Errorf("host %s has no UTR to unfold", host.Name)
+	}
+	return &design.StartFold{UTR: host.UTR}, nil
+}
+
+// BuildRules builds the rules of the rule profile of the host, with host
+// homology checked against the genome of the host.
+func (host Host) BuildRules() ([]problems.Rule, error) {
+	if host.Rules == "" {
+		return nil, fmt.Errorf("host %s has no rule profile", host.Name)
+	}
+	profile, err := problems.ReadProfile(host.Rules)
+	if err != nil {
+		return nil, err
+	}
+	profile.Genome = host.Genome
+	return profile.BuildRules()
+}
+
+// BuildCodonTables makes the codon table of each strategy with CDSs and
+// writes it to its codon table file, see design.TableSource, then returns the
+// paths it wrote.
+func (host Host) BuildCodonTables() ([]string, error) {
+	var paths []string
+	built := make(map[string]bool)
+	for _, strategy := range host.Strategies {
+		if len(strategy.CDSs) == 0 {
+			continue
+		}
+		if built[strategy.CodonTable] {
+			return nil, fmt.Errorf("strategies of host %s make the codon table %s twice", host.Name, strategy.CodonTable)
+		}
+		table, err := strategy.Build()
+		if err != nil {
+			return nil, fmt.Errorf("codon table of strategy %s of host %s: %w", strategy.Name, host.Name, err)
+		}
+		codon.WriteCodonJSON(table, strategy.CodonTable)
+		built[strategy.CodonTable] = true
+		paths = append(paths, strategy.CodonTable)
+	}
+	return paths, nil
+}
+
+// CodonTables reads the codon table of each strategy, by strategy name, as
+// BuildCodonTables wrote it.
+func (host Host) CodonTables() (map[string]codon.Table, error) {
+	tables := make(map[string]codon.Table)
+	for _, strategy := range host.Strategies {
+		// codon.ReadCodonJSON doesn't return its errors
+		if _, err := os.Stat(strategy.CodonTable); err != nil {
+			return nil, fmt.Errorf("codon table of strategy %s of host %s: %w", strategy.Name, host.Name, err)
+		}
+		tables[strategy.Name] = codon.ReadCodonJSON(strategy.CodonTable)
+	}
+	return tables, nil
+}
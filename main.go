@@ -1,284 +1,263 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"math/rand"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"strings"
-	"sync"
+	"runtime"
+	"strconv"
+	"time"
 
-	"github.com/Open-Science-Global/poly/checks"
+	"github.com/Open-Science-Global/friendzymes_toolkit/design"
+	"github.com/Open-Science-Global/friendzymes_toolkit/host"
+	"github.com/Open-Science-Global/friendzymes_toolkit/manifest"
 	"github.com/Open-Science-Global/poly/io/fasta"
-	"github.com/Open-Science-Global/poly/io/genbank"
-	"github.com/Open-Science-Global/poly/linearfold"
-	"github.com/Open-Science-Global/poly/synthesis"
-	"github.com/Open-Science-Global/poly/transform"
-	"github.com/Open-Science-Global/poly/transform/codon"
 )
 
 func main() {
-	// The goal is to take the list of enzymes and make CDS optimization using three different strategies:
-	// 1. Create a codon table for Bacillus Subtilis strain. KO7 and generate a optimized CDS
-	// 2. Create a codon table with starvation highly expressed genes in Bacillus Subtilis strain PY79 and optimize
-	// 3. Codon optimize for two species; e.g Bacillus Subtilis KO7 and E. coli K12
-
-	// To create a codon table we need a list of CDSs from the target organism and poly will take care of the rest for us
-	// Taking a list of fasta files to generate the respective codon tables
-	files := []string{"data/bsub-ko7-cdss.fasta", "data/bsub-py79-cdss-starvation.fasta", "data/bsub-py79-cdss.fasta", "data/ecoli-k12-cdss.fasta"}
-
-	codonTables := make(map[string]codon.Table)
-
-	for _, file := range files {
-		fileName := filepath.Base(file)[0 : len(file)-11]
-
-		fmt.Printf("Reading file %s...\n", fileName)
-		cdsSequences := fasta.Read(file)
-
-		// Create a single big string with all the CDSs
-		var allCdssFromFile strings.Builder
-		for _, cds := range cdsSequences {
-			allCdssFromFile.WriteString(cds.Sequence)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			serve(os.Args[2:])
+			return
+		case "utr":
+			designUTRs(os.Args[2:])
+			return
 		}
-		codingRegions := allCdssFromFile.String()
-
-		fmt.Printf("Creating table for %s...\n", fileName)
-		codonTable := codon.GetCodonTable(11)
-
-		fmt.Printf("Optimizing table for %s...\n", fileName)
-		optimizationTable := codonTable.OptimizeTable(codingRegions)
-		codonTables[fileName] = optimizationTable
-
-		codon.WriteCodonJSON(optimizationTable, "data/codon-table/"+fileName+".json")
-		fmt.Printf("\n")
 	}
 
-	//But before starting let's make a intersection between two codon tables, one from PY79 and another from K12
-	fmt.Println("Creating a codon table for both species: Bacillus Subtilis PY79 and E. coli K12...")
-	fmt.Printf("\n")
-	bsubCodonTable := codonTables["bsub-py79-cdss"]
-	ecoliCodonTable := codonTables["ecoli-k12-cdss"]
-
-	bothSpeciesTable, _ := codon.CompromiseCodonTable(bsubCodonTable, ecoliCodonTable, 0.1)
-
-	codon.WriteCodonJSON(bothSpeciesTable, "data/codon-table/bsub-ecoli.json")
-	codonTables["bsub-ecoli"] = bothSpeciesTable
-
-	starvationCodonTable := codonTables["bsub-py79-cdss-starvation"]
-	specialBothSpeciesTable, _ := codon.CompromiseCodonTable(starvationCodonTable, ecoliCodonTable, 0.1)
-
-	codon.WriteCodonJSON(specialBothSpeciesTable, "data/codon-table/starvation-ecoli.json")
-	codonTables["starvation-ecoli"] = specialBothSpeciesTable
+	seed := flag.Int64("seed", 0, "seed for codon optimization, a new one is picked and recorded in the manifest when 0")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of enzyme and strategy designs fixed at the same time")
+	timeout := flag.Duration("timeout", 0, "longest time each design may take to fix, e.g. 10m, no limit when 0")
+	hostName := flag.String("host", host.Default, "host profile in data/hosts, or the path of a host directory")
+	alternativeORFs := flag.Int("alternative-orfs", 0, "shorten alternative ORFs of at least this many codons when fixing designs, none when 0")
+	flag.Parse()
+
+	// The host sets the genome, codon table strategies and forbidden sites of the designs
+	hostProfile, err := host.Read(host.Directory, *hostName)
+	if err != nil {
+		fmt.Println("Could not read the host profile:", err)
+		os.Exit(1)
+	}
 
+	// Codons are picked at random by their weight, so the same seed gives the same output
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	fmt.Println("Seed:", *seed)
+	runManifest := manifest.New("friendzymes_toolkit", *seed)
+	runManifest.SetParameter("host", hostProfile.Name)
+	runManifest.SetParameter("alternative-orfs", strconv.Itoa(*alternativeORFs))
+	addManifestInput(runManifest, filepath.Join(hostProfile.Directory, "host.json"))
+
+	// To create a codon table we need a list of CDSs from the target organism and poly will take care of the rest for us.
+	// Each strategy of the host lists the CDSs its table is made from, see design.TableSource
+	fmt.Println("Creating and optimizing the codon tables of host", hostProfile.Name+"...")
+	tablePaths, err := hostProfile.BuildCodonTables()
+	if err != nil {
+		fmt.Println("Could not make the codon tables of the host:", err)
+		os.Exit(1)
+	}
+	cdsFiles := make(map[string]bool)
+	for _, strategy := range hostProfile.Strategies {
+		for _, files := range [][]string{strategy.CDSs, strategy.CompromiseCDSs} {
+			for _, file := range files {
+				if !cdsFiles[file] {
+					cdsFiles[file] = true
+					addManifestInput(runManifest, file)
+				}
+			}
+		}
+	}
+	for _, path := range tablePaths {
+		addManifestOutput(runManifest, path)
+	}
 	fmt.Println("Tables created and optimized! You could find each one as json files inside data/codon-table folder.")
 	fmt.Printf("\n")
 
+	if hostProfile.Genome == "" {
+		fmt.Println("Host", hostProfile.Name, "has no genome, designs are not checked for homology with it.")
+	}
+
+	// The strategies of the host use the tables just written
+	strategyTables, err := hostProfile.CodonTables()
+	if err != nil {
+		fmt.Println("Could not read the codon tables of the host:", err)
+		os.Exit(1)
+	}
 	fmt.Println("Creating a Kmer Table from Host Genome...")
 	fmt.Printf("\n")
 	// Another important think that we need is a 20-mer Table of the Host Genome
-	bsubGenomeFile := genbank.Read("data/bsub-py79-genome.gb")
-	bsubGenome := bsubGenomeFile.Sequence
-	hostGenomeKmerTable := getKmerTable(20, bsubGenome)
+	hostOptions, err := hostProfile.DesignOptions()
+	if err != nil {
+		fmt.Println("Could not read the host genome:", err)
+		os.Exit(1)
+	}
+	if hostProfile.Genome != "" {
+		addManifestInput(runManifest, hostProfile.Genome)
+	}
 
 	fmt.Println("Host Kmer Table created!")
 	fmt.Printf("\n")
 
 	// Taking the list of enzymes to codon optimize for each STRATEGY and eliminate some problems
 	enzymes := fasta.Read("data/enzymes.fasta")
+	addManifestInput(runManifest, "data/enzymes.fasta")
 
-	var output []fasta.Fasta
-	for _, enzyme := range enzymes {
-		fmt.Println("Codon Optimizing using Strategies 1, 2 and 3...")
-		// Strategy #1:  Create a codon table for Bacillus Subtilis strain. KO7
-		bsubKO7CodonTable := codonTables["bsub-ko7-cdss"]
-		enzymeOptimizedBsub := CodonOptimization(enzyme.Sequence, bsubKO7CodonTable)
-
-		// Strategy #2:  Create a codon table with starvation highly expressed genes
-		bsubStarvationCodonTable := codonTables["bsub-py79-cdss-starvation"]
-		enzymeOptimizedStarvation := CodonOptimization(enzyme.Sequence, bsubStarvationCodonTable)
-
-		// Strategy #3:  Codon optimize for two species; e.g Bacillus Subtilis KO7 and E. coli K12
-		bsubEcoliCodonTable := codonTables["bsub-ecoli"]
-		enzymeOptimizedBsubEcoli := CodonOptimization(enzyme.Sequence, bsubEcoliCodonTable)
-
-		// Strategy #3:  Codon optimize for two species; e.g Bacillus Subtilis KO7 and E. coli K12
-		starvationEcoliCodonTable := codonTables["starvation-ecoli"]
-		enzymeOptimizedStarvationEcoli := CodonOptimization(enzyme.Sequence, starvationEcoliCodonTable)
+	// Regions of each enzyme CDS, like tags and protease sites, that fixing problems must not change
+	lockedRegions, err := design.ReadLockedRegions("data/locked-regions.json")
+	if err != nil {
+		fmt.Println("Could not read locked regions:", err)
+		os.Exit(1)
+	}
 
-		fmt.Println("Fixing optimized sequences if it has any problems...")
+	// Native CDSs of the enzymes, with the codon tables of their organisms, for harmonization strategies
+	nativeCDSs, err := design.ReadNativeCDSs("data/native-cdss.json")
+	if err != nil {
+		fmt.Println("Could not read native CDSs:", err)
+		os.Exit(1)
+	}
 
-		strategyOne := fixSequence(enzymeOptimizedBsub, bsubKO7CodonTable, hostGenomeKmerTable)
-		//comparingResults("Strategy#1", enzymeOptimizedBsub, strategyOne)
-		//copyStrategyOne := TwoCdsWithoutRepetition(strategyOne, bsubKO7CodonTable)
-		strategyTwo := fixSequence(enzymeOptimizedStarvation, bsubStarvationCodonTable, hostGenomeKmerTable)
-		//comparingResults("Strategy#2", enzymeOptimizedStarvation, strategyTwo)
-		//copyStrategyTwo := TwoCdsWithoutRepetition(strategyTwo, bsubStarvationCodonTable)
-		strategyThree := fixSequence(enzymeOptimizedBsubEcoli, bsubEcoliCodonTable, hostGenomeKmerTable)
-		//comparingResults("Strategy#3", enzymeOptimizedBsubEcoli, strategyThree)
-		//copyStrategyThree := TwoCdsWithoutRepetition(strategyThree, bsubEcoliCodonTable)
-		strategyFour := fixSequence(enzymeOptimizedStarvationEcoli, starvationEcoliCodonTable, hostGenomeKmerTable)
-		//comparingResults("Strategy#4", enzymeOptimizedStarvationEcoli, strategyFour)
+	// Each enzyme is codon optimized with the codon table of every strategy of the host, for PY79:
+	// 1. Create a codon table for Bacillus Subtilis strain. KO7
+	// 2. Create a codon table with starvation highly expressed genes
+	// 3. Codon optimize for two species; e.g Bacillus Subtilis KO7 and E. coli K12
+	// 4. Codon optimize for starvation genes and E. coli K12
+	// 5. Harmonize the native CDS for Bacillus Subtilis KO7, keeping its rare codons rare
+	var jobs []design.Job
+	for _, enzyme := range enzymes {
+		for _, strategy := range hostProfile.Strategies {
+			name := enzyme.Name + " | Codon Optimized By " + strategy.Description
+			options := hostOptions
+			options.LockedRegions = lockedRegions[enzyme.Name]
+			options.Rand = rand.New(rand.NewSource(jobSeed(*seed, name)))
+			options.AlternativeORFCodons = *alternativeORFs
+			job := design.Job{
+				Name:       name,
+				Protein:    enzyme.Sequence,
+				CodonTable: strategyTables[strategy.Name],
+				Options:    options,
+			}
+			// A 5' codon ramp starts the CDS with rarer codons, see design.Ramp
+			ramp, err := strategy.DesignRamp()
+			if err != nil {
+				fmt.Println("Could not read the ramp of", strategy.Description+":", err)
+				os.Exit(1)
+			}
+			job.Options.Ramp = ramp
+			// Unfolding the 5' end of the mRNA is opt-in, see design.StartFold
+			if job.Options.StartFold, err = hostProfile.DesignStartFold(strategy); err != nil {
+				fmt.Println("Could not read the start fold of", strategy.Description+":", err)
+				os.Exit(1)
+			}
+			if strategy.Harmonize {
+				native, ok := nativeCDSs[enzyme.Name]
+				if !ok {
+					fmt.Println("No native CDS of", enzyme.Name+", skipping", strategy.Description)
+					continue
+				}
+				job.Optimize = design.Harmonizer(native, job.CodonTable)
+			}
+			jobs = append(jobs, job)
+		}
+	}
 
-		output = append(output,
-			fasta.Fasta{enzyme.Name + " | Codon Optimized By Strategy #1 Bacillus Subtilis KO7", strategyOne},
-			//fasta.Fasta{enzyme.Name + " | Copy without repetititon of Codon Optimized By Strategy #1 Bacillus Subtilis KO7", copyStrategyOne},
-			fasta.Fasta{enzyme.Name + " | Codon Optimized By Strategy #2 Bacillus Subtilis Starvation Genes", strategyTwo},
-			//fasta.Fasta{enzyme.Name + " | Copy without repetititon of Codon Optimized By Strategy #2 Bacillus Subtilis Starvation Genes", copyStrategyTwo},
-			fasta.Fasta{enzyme.Name + " | Codon Optimized By Strategy #3 Both species Bacillus Subtilis KO7 and E. coli K12", strategyThree},
-			//fasta.Fasta{enzyme.Name + " | Copy without repetititon of Codon Optimized By Strategy #3 Both species Bacillus Subtilis KO7 and E. coli K12", copyStrategyThree},
-			fasta.Fasta{enzyme.Name + " | Codon Optimized By Strategy #4 Bacillus Subtilis Starvation genes and E. coli K12", strategyFour},
-		)
+	// Stop the jobs left on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Codon Optimizing and fixing problems of %d enzymes using %d strategies of host %s on %d workers...\n", len(enzymes), len(hostProfile.Strategies), hostProfile.Name, *workers)
+	results := design.DesignAll(ctx, jobs, design.PoolOptions{
+		Workers: *workers,
+		Timeout: *timeout,
+		Progress: func(done int, total int, result design.JobResult) {
+			status := "done"
+			if result.Err != nil {
+				status = "failed: " + result.Err.Error()
+			}
+			fmt.Printf("[%d/%d] %s %s in %s\n", done, total, result.Job.Name, status, result.Duration.Round(time.Millisecond))
+		},
+	})
 
+	var output []fasta.Fasta
+	// Ramps are scored on their own: they should use rarer codons, have less GC and fold less than the rest of the CDS
+	rampScores := make(map[string]design.RampScore)
+	// Long alternative ORFs burden expression and out of frame stops end frameshifted ribosomes early
+	orfAnalyses := make(map[string]design.ORFAnalysis)
+	failed := false
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Println("Could not design", result.Job.Name+":", result.Err)
+			failed = true
+			continue
+		}
+		for _, problem := range result.Result.LockedProblems {
+			fmt.Println(result.Job.Name, "problem left in a locked region:", problem)
+		}
+		output = append(output, fasta.Fasta{Name: result.Job.Name, Sequence: result.Result.Sequence})
+		analysis := design.AnalyzeORFs(result.Result.Sequence, *alternativeORFs)
+		orfAnalyses[result.Job.Name] = analysis
+		fmt.Printf("%s: %d alternative ORFs of %d codons or more, longest %d, %.1f out of frame stops per 100 codons\n", result.Job.Name, len(analysis.AlternativeORFs), analysis.MinCodons, analysis.LongestORF, analysis.StopDensity)
+		if ramp := result.Job.Options.Ramp; ramp != nil {
+			score := ramp.Score(result.Result.Sequence, result.Job.CodonTable)
+			rampScores[result.Job.Name] = score
+			fmt.Printf("%s ramp of %d codons: GC %.2f (rest %.2f), adaptation %.2f (rest %.2f), fold score %.1f\n", result.Job.Name, score.Codons, score.RampGC, score.RestGC, score.RampAdaptation, score.RestAdaptation, score.RampFold)
+		}
+	}
+	if failed {
+		os.Exit(1)
 	}
 	fmt.Println("Writing outputs...")
 	fasta.Write(output, "data/output/output.fasta")
-
-	fmt.Println("Finished! Check your fasta file with the results in data/output directory.")
-
-}
-
-func CodonOptimization(enzymeSequence string, codonTable codon.Table) string {
-	// Poly generally makes Codon Optimization by receiving a list of protein sequences, but we actually have now CDSs
-	// So we should first translate CDSs. We will be using the Eubacterial genetic code table 11, you could take a look
-	// at this table in https://www.ncbi.nlm.nih.gov/Taxonomy/Utils/wprintgc.cgi
-
-	// Optimize sequence using the protein sequence and codon table
-	optimizedSequence, _ := codon.Optimize(enzymeSequence, codonTable)
-
-	// Lets check if the codon optimization actually works by making some checks:
-	// First one is if both codon sequences are different
-	if optimizedSequence == enzymeSequence {
-		fmt.Println("Both sequences are equal, some problem occur. They should be different because one is optimized. Checks what happened and run again.")
-		os.Exit(0)
+	addManifestOutput(runManifest, "data/output/output.fasta")
+	if len(rampScores) > 0 {
+		file, _ := json.MarshalIndent(rampScores, "", " ")
+		if err := ioutil.WriteFile("data/output/ramp-scores.json", file, 0644); err != nil {
+			fmt.Println("Could not write the ramp scores:", err)
+		} else {
+			addManifestOutput(runManifest, "data/output/ramp-scores.json")
+		}
 	}
 
-	// Check if both translated sequences are equal
-	protein, _ := codon.Translate(optimizedSequence, codon.GetCodonTable(11))
-	if protein != enzymeSequence {
-		fmt.Println("These protein sequences aren't equal, some problem occur. They should be equal because codon optimization don't change any aminoacid.")
-		os.Exit(0)
+	file, _ := json.MarshalIndent(orfAnalyses, "", " ")
+	if err := ioutil.WriteFile("data/output/orfs.json", file, 0644); err != nil {
+		fmt.Println("Could not write the ORF analyses:", err)
+	} else {
+		addManifestOutput(runManifest, "data/output/orfs.json")
 	}
-	return optimizedSequence
-}
-
-// List of sequences that we should avoid in our software
-func forbiddenSequencesList() []string {
-	BsaI_bind_5prime := "GGTCTC" //5" GGTCTC N|      3"
-	//3" CCAGAG N NNNN| 5"
-	BsaI_bind_3prime := "GAGACC" //5" |NNNN N GAGACC 3"
-
-	BbsI_bind_5prime := "GAAGAC" //5" GAAGAC NN|
-	//3" CTTCTG NN NNNN|
-	BbsI_bind_3prime := "GTCTTC" //5" |NNNN NN GTCTTC 3"
-
-	BtgzI_bind_5prime := "GCGATG" //5" GCGATG NNN NNN NNN N|      3"
-	//3" CGCTAC NNN NNN NNN N NNNN| 5"
-	BtgzI_bind_3prime := "CATCGC" //5" |NNNN N NNN NNN NNN CATCGC 3"
-	//3"      |N NNN NNN NNN GTAGCG 5"
-
-	SapI_bind_5prime := "GCTCTTC" //5" GCTCTTC N|     3"
-	//3" CGAGAAG N NNN| 5"
-	SapI_bind_3prime := "GAAGAGC" //5" |NNN N GAAGAGC 3"
-	//3"     |N CTTCTCG 5"
-
-	BsmbI_bind_5prime := "CGTCTC" //5" CGTCTC N|      3"
-	//3" GCAGAG N NNNN| 5"
-	BsmbI_bind_3prime := "GAGACG" //5" |NNNN N GAGACG 3"
-	//3"      |N CTCTGC 5"
-
-	AarI_bind_5prime := "CACCTGC" //5" CACCTGC N NNN|       3"
-	//3" GTGGACG N NNN NNNN|  5"
-	AarI_bind_3prime := "GCAGGTG" //5" |NNNN NNN N GCAGGTG 3"
-	//3"      |NNN N CGTCCAC 5"
-
-	PmeI_bind := "GTTTAAAC" //5" GTTT|AAAC 3" blunt cutter
 
-	forbiddenSeqList := []string{BsaI_bind_5prime,
-		BsaI_bind_3prime,
-		BbsI_bind_5prime,
-		BbsI_bind_3prime,
-		SapI_bind_5prime,
-		SapI_bind_3prime,
-		BsmbI_bind_5prime,
-		BsmbI_bind_3prime,
-		BtgzI_bind_5prime,
-		BtgzI_bind_3prime,
-		AarI_bind_5prime,
-		AarI_bind_3prime,
-		PmeI_bind,
-		"AAAAA",
-		"CCCCC",
-		"GGGGG",
-		"TTTTT"}
-	return forbiddenSeqList
-}
-
-// getKmerTable receive a sequence string and a k int and generates a set of unique k-mers
-func getKmerTable(k int, sequence string) map[string]bool {
-	kmers := make(map[string]bool)
-	for i := 0; i <= len(sequence)-k; i++ {
-		kmers[strings.ToUpper(sequence[i:i+k])] = true
+	if err := runManifest.Write("data/output/output.manifest.json"); err != nil {
+		fmt.Println("Could not write the run manifest:", err)
+		os.Exit(1)
 	}
 
-	return kmers
-}
-
-func fixSequence(sequence string, codonTable codon.Table, genomeKmerTable map[string]bool) string {
-	// Construct function that will remove unwanted properties in our sequences
-	// Function#1: Remove unwanted sequences as restriction binding sites and homopolymers of length 5
-	forbiddenSequences := forbiddenSequencesList()
-	removeSequenceFunc := synthesis.RemoveSequence(forbiddenSequences)
-
-	// Function#2: Remove secondary structures
-	removeSecondaryFunc := synthesis.RemoveHairpin(20, 200)
-
-	// Function#3: Remove repetition greater than 10 inside the sequence
-	removeRepeatFunc := synthesis.RemoveRepeat(10)
-
-	// Function#4: Remove repetitions between sequence and host genome
-	genomeRemoveRepeatFunc := synthesis.GlobalRemoveRepeat(20, genomeKmerTable)
-
-	// Function#5: Fix a GC content if necessary
-	//gcContentFixFunc := synthesis.GcContentFixer(0.4, 0.6)
-	// Added all those functions to a list and pass through FixCds function that will take care of our problems
-	var functions []func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup)
-	functions = append(functions, removeSequenceFunc, removeRepeatFunc, genomeRemoveRepeatFunc, removeSecondaryFunc)
+	fmt.Println("Finished! Check your fasta file with the results in data/output directory.")
 
-	fixedSeq, _, _ := synthesis.FixCds(":memory:", sequence, codonTable, functions)
-	// Because FixCds actually remove stop codon we will concatenate it
-	return fixedSeq + "TAA"
 }
 
-func TwoCdsWithoutRepetition(sequence string, codonTable codon.Table) string {
-
-	forbiddenSequences := forbiddenSequencesList()
-	removeSequenceFunc := synthesis.RemoveSequence(forbiddenSequences)
-
-	removeRepeatFunc := synthesis.RemoveRepeat(10)
-
-	globalRemoveRepeatFunc := synthesis.GlobalRemoveRepeat(20, getKmerTable(20, sequence))
-
-	var functions []func(string, chan synthesis.DnaSuggestion, *sync.WaitGroup)
-	functions = append(functions, removeSequenceFunc, removeRepeatFunc, globalRemoveRepeatFunc)
-
-	fixedSeq, _, _ := synthesis.FixCds(":memory:", sequence, codonTable, functions)
-	// Because FixCds actually remove stop codon we will concatenate it
-	return fixedSeq + "TAA"
+// jobSeed is the seed of the codons of one design, made from the seed of the run and the name of the design. Each
+// design has its own random source, so it doesn't change when other enzymes or strategies are added or removed.
+func jobSeed(seed int64, name string) int64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(name))
+	return seed ^ int64(hash.Sum64())
 }
 
-func comparingResults(title string, sequence string, fixed string) {
-	fmt.Println(title + " - Stats")
-	fmt.Println("GC Content: ", checks.GcContent(fixed))
-	fmt.Println("Free energy before:")
-	secondaryStructureData(sequence)
-	fmt.Println("Free energy after:")
-	secondaryStructureData(fixed)
+func addManifestInput(runManifest *manifest.Manifest, path string) {
+	if err := runManifest.AddInput(path); err != nil {
+		fmt.Println("Could not add input to the run manifest:", err)
+	}
 }
 
-func secondaryStructureData(sequence string) {
-
-	transcript := transform.Transcription(sequence)
-	_, energy := linearfold.CONTRAfoldV2(transcript, linearfold.DefaultBeamSize)
-	//fmt.Println("Dot Bracket format: ", dot_bracket)
-	fmt.Println(energy)
-
+func addManifestOutput(runManifest *manifest.Manifest, path string) {
+	if err := runManifest.AddOutput(path); err != nil {
+		fmt.Println("Could not add output to the run manifest:", err)
+	}
 }
@@ -0,0 +1,127 @@
+// Package manifest records what went into a run of our tools, so every output
+// file can be made again byte for byte: checksums of the inputs and outputs,
+// the tool version, its parameters and the random seed.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+// Manifest describes one run of a tool.
+type Manifest struct {
+	Tool       string            `json:"tool"`
+	Version    string            `json:"version"`
+	Date       string            `json:"date"`
+	Seed       int64             `json:"seed"`
+	Parameters map[string]string `json:"parameters"`
+	Inputs     []File            `json:"inputs"`
+	Outputs    []File            `json:"outputs"`
+}
+
+// File is an input or output of a run with its sha256 checksum.
+type File struct {
+	Path   string `json:"path"`
+	Sha256 string `json:"sha256"`
+}
+
+// New starts the manifest of a run of tool with the given seed.
+func New(tool string, seed int64) *Manifest {
+	return &Manifest{
+		Tool:       tool,
+		Version:    Version(),
+		Date:       time.Now().UTC().Format(time.RFC3339),
+		Seed:       seed,
+		Parameters: make(map[string]string),
+	}
+}
+
+// Version is the module version and, when the binary was built from a git
+// checkout, the revision it was built from.
+func Version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			version += " " + setting.Value
+		case "vcs.modified":
+			if setting.Value == "true" {
+				version += " (modified)"
+			}
+		}
+	}
+	return version
+}
+
+// SetParameter records a parameter of the run.
+func (manifest *Manifest) SetParameter(name string, value string) {
+	manifest.Parameters[name] = value
+}
+
+// AddInput records the checksum of an input file.
+func (manifest *Manifest) AddInput(path string) error {
+	file, err := checksum(path)
+	if err != nil {
+		return err
+	}
+	manifest.Inputs = append(manifest.Inputs, file)
+	return nil
+}
+
+// AddOutput records the checksum of an output file, so it must be called
+// after the file is written.
+func (manifest *Manifest) AddOutput(path string) error {
+	file, err := checksum(path)
+	if err != nil {
+		return err
+	}
+	manifest.Outputs = append(manifest.Outputs, file)
+	return nil
+}
+
+// Write writes the manifest as json.
+func (manifest *Manifest) Write(path string) error {
+	file, err := json.MarshalIndent(manifest, "", " ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, file, 0644)
+}
+
+// Read reads a manifest written by Write, e.g. to run again with its seed and
+// parameters.
+func Read(path string) (*Manifest, error) {
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(file, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+func checksum(path string) (File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return File{}, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return File{}, err
+	}
+	return File{Path: path, Sha256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
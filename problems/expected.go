@@ -0,0 +1,175 @@
+// Package problems finds the problems of our parts with rules, and sorts
+// them so the ones we put there on purpose, like the BsmBI sites of a
+// receiver, aren't annotated as problems.
+package problems
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/goldengate"
+	"github.com/Open-Science-Global/poly"
+	"github.com/Open-Science-Global/poly/transform"
+)
+
+// Expectation declares a site that a part is meant to have.
+type Expectation struct {
+	// Enzyme names one of goldengate.Enzymes, Site can be used instead for
+	// any other sequence.
+	Enzyme string `json:"enzyme"`
+	Site   string `json:"site"`
+	// Count is how many of these sites are expected, any number when 0.
+	Count int `json:"count"`
+	// Start and End limit where the sites are expected, anywhere when End is 0.
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// site returns the sequence of the expected site.
+func (expectation Expectation) site() (string, error) {
+	if expectation.Site != "" {
+		return strings.ToUpper(expectation.Site), nil
+	}
+	enzyme, err := goldengate.GetEnzyme(expectation.Enzyme)
+	if err != nil {
+		return "", err
+	}
+	return enzyme.Site, nil
+}
+
+// Split sorts the problems found in a sequence into the expected ones, which
+// are sites declared by the expectations, and the unexpected ones. Problems are
+// assigned to the expectations in order, so when a site is expected Count
+// times any further one is unexpected.
+func Split(sequence string, problems []Problem, expectations []Expectation) ([]Problem, []Problem, error) {
+	sequence = strings.ToUpper(sequence)
+	used := make([]int, len(expectations))
+	var expected, unexpected []Problem
+
+	for _, problem := range problems {
+		match := problem.Match
+		isExpected := false
+		for i, expectation := range expectations {
+			site, err := expectation.site()
+			if err != nil {
+				return nil, nil, err
+			}
+			if expectation.Count > 0 && used[i] >= expectation.Count {
+				continue
+			}
+			if expectation.End > 0 && (match.Start < expectation.Start || match.End > expectation.End) {
+				continue
+			}
+			if !matchesSite(sequence, match, site) {
+				continue
+			}
+			used[i]++
+			isExpected = true
+			break
+		}
+		if isExpected {
+			expected = append(expected, problem)
+		} else {
+			unexpected = append(unexpected, problem)
+		}
+	}
+	return expected, unexpected, nil
+}
+
+// matchesSite checks if a match is the site, on either strand. Matches that
+// cover more than the site, like a repeat that happens to contain it, are not.
+func matchesSite(sequence string, match Match, site string) bool {
+	if match.Start < 0 || match.Start >= len(sequence) || match.End-match.Start != len(site) {
+		return false
+	}
+	var region string
+	switch {
+	case match.End-len(sequence) > match.Start:
+		return false
+	case match.End > len(sequence):
+		// Matches across the origin of a circular sequence go on from its start
+		region = sequence[match.Start:] + sequence[:match.End-len(sequence)]
+	default:
+		region = sequence[match.Start:match.End]
+	}
+	return region == site || region == transform.ReverseComplement(site)
+}
+
+// ParseExpectations reads sites written like BsaI:2,BbsI:2, the form taken by
+// the -expect flag of our scripts. Sites without a count are expected any
+// number of times.
+func ParseExpectations(expect string) ([]Expectation, error) {
+	var expectations []Expectation
+	for _, site := range strings.Split(expect, ",") {
+		if site == "" {
+			continue
+		}
+		fields := strings.SplitN(site, ":", 2)
+		expectation := Expectation{Enzyme: fields[0]}
+		if len(fields) == 2 {
+			count, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("count of %s: %w", fields[0], err)
+			}
+			expectation.Count = count
+		}
+		expectations = append(expectations, expectation)
+	}
+	return expectations, nil
+}
+
+// FromStandard declares the sites every part made with a part standard has,
+// the two sites of each of its levels.
+func FromStandard(standard goldengate.Standard) []Expectation {
+	var expectations []Expectation
+	index := make(map[string]int)
+	for _, level := range standard.Levels {
+		if i, ok := index[level.Enzyme]; ok {
+			expectations[i].Count += 2
+			continue
+		}
+		index[level.Enzyme] = len(expectations)
+		expectations = append(expectations, Expectation{Enzyme: level.Enzyme, Count: 2})
+	}
+	return expectations
+}
+
+// FromFeatures declares a site for every feature of the sequence that names
+// one of goldengate.Enzymes in its type, label or note, e.g. a "BsmBI site"
+// misc_feature, expected inside the feature.
+func FromFeatures(sequence poly.Sequence) []Expectation {
+	var expectations []Expectation
+	for _, feature := range sequence.Features {
+		description := strings.ToLower(feature.Type + " " + feature.Attributes["label"] + " " + feature.Attributes["note"])
+		for _, name := range enzymeNames() {
+			if strings.Contains(description, strings.ToLower(name)) {
+				expectations = append(expectations, Expectation{
+					Enzyme: name,
+					Start:  feature.SequenceLocation.Start,
+					End:    feature.SequenceLocation.End,
+				})
+			}
+		}
+	}
+	return expectations
+}
+
+// Describe summarizes the expected problems of a part for logs.
+func Describe(name string, expected []Problem) string {
+	var positions []string
+	for _, problem := range expected {
+		positions = append(positions, fmt.Sprintf("%d-%d", problem.Start, problem.End))
+	}
+	return fmt.Sprintf("%s: %d expected sites %s", name, len(expected), strings.Join(positions, " "))
+}
+
+func enzymeNames() []string {
+	var names []string
+	for name := range goldengate.Enzymes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,202 @@
+package problems
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Open-Science-Global/poly"
+)
+
+// Report is the JSON export of the problems of one sequence.
+type Report struct {
+	Sequence string   `json:"sequence"`
+	Length   int      `json:"length"`
+	Circular bool     `json:"circular"`
+	Problems []Record `json:"problems"`
+}
+
+// Record is a problem as it is written to JSON. Start is 0-based and End is
+// not included, like in poly.Location, and past Length for problems across
+// the origin of a circular sequence.
+type Record struct {
+	Rule     string   `json:"rule"`
+	Category Category `json:"category"`
+	Severity Severity `json:"severity"`
+	Start    int      `json:"start"`
+	End      int      `json:"end"`
+	Message  string   `json:"message"`
+}
+
+// Export writes the problems of a sequence to path, as GFF3, BED or JSON
+// depending on its extension: .gff or .gff3, .bed or .json.
+func Export(path string, sequence poly.Sequence, problems []Problem) error {
+	var file []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gff", ".gff3":
+		file = BuildGff(sequence, problems)
+	case ".bed":
+		file = BuildBed(sequence, problems)
+	case ".json":
+		file, err = BuildJSON(sequence, problems)
+	default:
+		return fmt.Errorf("unknown problem export format %q, use .gff, .bed or .json", filepath.Ext(path))
+	}
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, file, 0644)
+}
+
+// BuildGff writes the problems as GFF3 features of the sequence. Their rule,
+// category, severity and message are attributes, and their color is the ApE
+// color of their severity. Circular sequences get a region marked
+// Is_circular, which lets problems across the origin end past its length.
+func BuildGff(sequence poly.Sequence, problems []Problem) []byte {
+	var gff bytes.Buffer
+	gff.WriteString("##gff-version 3\n")
+	seqid := gffSeqID(sequenceName(sequence))
+	fmt.Fprintf(&gff, "##sequence-region %s 1 %d\n", seqid, len(sequence.Sequence))
+	if sequence.Meta.Locus.Circular {
+		fmt.Fprintf(&gff, "%s\tfriendzymes_toolkit\tregion\t1\t%d\t.\t.\t.\tID=%s;Is_circular=true\n", seqid, len(sequence.Sequence), seqid)
+	}
+	for i, problem := range problems {
+		attributes := []string{
+			"ID=" + gffEscape(fmt.Sprintf("%s-%d", problem.Rule, i+1)),
+			"Name=" + gffEscape(problem.Rule),
+			"Note=" + gffEscape(problem.Message),
+			"category=" + gffEscape(string(problem.Category)),
+			"severity=" + gffEscape(string(problem.Severity)),
+			"color=" + gffEscape(severityColors[problem.Severity]),
+		}
+		// GFF3 positions start at 1 and include the last base
+		fmt.Fprintf(&gff, "%s\tfriendzymes_toolkit\tsequence_feature\t%d\t%d\t.\t.\t.\t%s\n",
+			seqid, problem.Start+1, problem.End, strings.Join(attributes, ";"))
+	}
+	return gff.Bytes()
+}
+
+// BuildBed writes the problems as BED9 lines of the sequence, named with any
+// whitespace in its name replaced by underscores. The rule is the name of each
+// line, with a score by severity and the severity color as itemRgb. BED has no
+// circular sequences, so problems across the origin are split in two lines.
+func BuildBed(sequence poly.Sequence, problems []Problem) []byte {
+	var bed bytes.Buffer
+	name := strings.Join(strings.Fields(sequenceName(sequence)), "_")
+	for _, problem := range problems {
+		var locations []poly.Location
+		if problemLocation := location(problem, len(sequence.Sequence)); problemLocation.Join {
+			locations = problemLocation.SubLocations
+		} else {
+			locations = []poly.Location{problemLocation}
+		}
+		for _, location := range locations {
+			fmt.Fprintf(&bed, "%s\t%d\t%d\t%s\t%d\t.\t%d\t%d\t%s\n",
+				name, location.Start, location.End, problem.Rule, severityScores[problem.Severity],
+				location.Start, location.End, rgb(severityColors[problem.Severity]))
+		}
+	}
+	return bed.Bytes()
+}
+
+// BuildJSON writes the problems as a Report.
+func BuildJSON(sequence poly.Sequence, problems []Problem) ([]byte, error) {
+	report := Report{
+		Sequence: sequenceName(sequence),
+		Length:   len(sequence.Sequence),
+		Circular: sequence.Meta.Locus.Circular,
+		Problems: Records(problems),
+	}
+	return json.MarshalIndent(report, "", " ")
+}
+
+// Records turns problems into the records written to JSON. It never returns
+// nil, so no problems are written as an empty list.
+func Records(problems []Problem) []Record {
+	records := []Record{}
+	for _, problem := range problems {
+		records = append(records, Record{
+			Rule:     problem.Rule,
+			Category: problem.Category,
+			Severity: problem.Severity,
+			Start:    problem.Start,
+			End:      problem.End,
+			Message:  problem.Message,
+		})
+	}
+	return records
+}
+
+// ReadJSON reads a Report written by BuildJSON, e.g. to compare the problems
+// of two revisions of a part.
+func ReadJSON(path string) (Report, error) {
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Report{}, err
+	}
+	var report Report
+	err = json.Unmarshal(file, &report)
+	return report, err
+}
+
+// sequenceName is the locus name of a sequence, or its name when it has no
+// locus, like sequences read from FASTA.
+func sequenceName(sequence poly.Sequence) string {
+	if sequence.Meta.Locus.Name != "" {
+		return sequence.Meta.Locus.Name
+	}
+	return sequence.Meta.Name
+}
+
+// severityScores are the BED scores of each severity, which browsers use to
+// shade features.
+var severityScores = map[Severity]int{
+	Block: 1000,
+	Warn:  500,
+	Info:  100,
+}
+
+// gffEscape percent encodes the characters that have a meaning in GFF3
+// columns and attributes.
+func gffEscape(value string) string {
+	var escaped strings.Builder
+	for _, character := range []byte(value) {
+		switch character {
+		case ';', '=', '&', ',', '%', '\t', '\n', '\r':
+			fmt.Fprintf(&escaped, "%%%02X", character)
+		default:
+			escaped.WriteByte(character)
+		}
+	}
+	return escaped.String()
+}
+
+// gffSeqID percent encodes the characters GFF3 doesn't allow in a sequence
+// ID, like the spaces of FASTA names.
+func gffSeqID(name string) string {
+	var escaped strings.Builder
+	for _, character := range []byte(name) {
+		switch {
+		case 'a' <= character && character <= 'z', 'A' <= character && character <= 'Z', '0' <= character && character <= '9',
+			strings.IndexByte(".:^*$@!+_?-|", character) >= 0:
+			escaped.WriteByte(character)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", character)
+		}
+	}
+	return escaped.String()
+}
+
+// rgb turns a color like #f44336 into the 244,67,54 form of BED.
+func rgb(color string) string {
+	value, err := strconv.ParseUint(strings.TrimPrefix(color, "#"), 16, 32)
+	if err != nil {
+		return "0"
+	}
+	return fmt.Sprintf("%d,%d,%d", value>>16&0xff, value>>8&0xff, value&0xff)
+}
@@ -0,0 +1,46 @@
+package problems
+
+import (
+	"testing"
+
+	"github.com/Open-Science-Global/poly"
+)
+
+func TestBuildGffAndBed(t *testing.T) {
+	site := Problem{Match: Match{Start: 2, End: 8, Message: "BsaI site; GGTCTC"}, Rule: "restriction-sites", Category: RestrictionSite, Severity: Block}
+	origin := Problem{Match: Match{Start: 18, End: 23, Message: "Repeat"}, Rule: "repeats", Category: Repeat, Severity: Warn}
+
+	linear := poly.Sequence{Sequence: "AAGGTCTCAAAAAAAAAAAA"}
+	linear.Meta.Name = "part 1"
+	circular := linear
+	circular.Meta.Locus.Circular = true
+
+	tests := []struct {
+		name     string
+		sequence poly.Sequence
+		problems []Problem
+		gff      string
+		bed      string
+	}{
+		{"linear", linear, []Problem{site},
+			"##gff-version 3\n" +
+				"##sequence-region part%201 1 20\n" +
+				"part%201\tfriendzymes_toolkit\tsequence_feature\t3\t8\t.\t.\t.\tID=restriction-sites-1;Name=restriction-sites;Note=BsaI site%3B GGTCTC;category=restriction site;severity=block;color=#f44336\n",
+			"part_1\t2\t8\trestriction-sites\t1000\t.\t2\t8\t244,67,54\n"},
+		{"across the origin", circular, []Problem{origin},
+			"##gff-version 3\n" +
+				"##sequence-region part%201 1 20\n" +
+				"part%201\tfriendzymes_toolkit\tregion\t1\t20\t.\t.\t.\tID=part%201;Is_circular=true\n" +
+				"part%201\tfriendzymes_toolkit\tsequence_feature\t19\t23\t.\t.\t.\tID=repeats-1;Name=repeats;Note=Repeat;category=repeat;severity=warn;color=#ff9800\n",
+			"part_1\t18\t20\trepeats\t500\t.\t18\t20\t255,152,0\n" +
+				"part_1\t0\t3\trepeats\t500\t.\t0\t3\t255,152,0\n"},
+	}
+	for _, test := range tests {
+		if gff := string(BuildGff(test.sequence, test.problems)); gff != test.gff {
+			t.Errorf("%s: GFF\n%s\nwant\n%s", test.name, gff, test.gff)
+		}
+		if bed := string(BuildBed(test.sequence, test.problems)); bed != test.bed {
+			t.Errorf("%s: BED\n%s\nwant\n%s", test.name, bed, test.bed)
+		}
+	}
+}
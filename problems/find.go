@@ -0,0 +1,119 @@
+package problems
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Open-Science-Global/poly/transform"
+)
+
+// Match is a stretch of a sequence that a finder function flags. Start is
+// 0-based and End is not included, like in poly.Location.
+type Match struct {
+	Start   int
+	End     int
+	Message string
+}
+
+// AvoidSequences is a finder function for forbidden sequences, like enzyme
+// sites and homopolymers. Each occurrence of a sequence, on either strand, is
+// a match of its length.
+func AvoidSequences(sequences []string) func(string) []Match {
+	return func(sequence string) []Match {
+		sequence = strings.ToUpper(sequence)
+		var matches []Match
+		for _, forbidden := range sequences {
+			forbidden = strings.ToUpper(forbidden)
+			reverse := transform.ReverseComplement(forbidden)
+			for _, start := range findAll(sequence, forbidden) {
+				matches = append(matches, Match{Start: start, End: start + len(forbidden), Message: "Forbidden sequence " + forbidden})
+			}
+			// Palindromic sequences are the same on both strands
+			if reverse == forbidden {
+				continue
+			}
+			for _, start := range findAll(sequence, reverse) {
+				matches = append(matches, Match{Start: start, End: start + len(forbidden), Message: "Forbidden sequence " + forbidden + " on the reverse strand"})
+			}
+		}
+		return matches
+	}
+}
+
+// AvoidRepeats is a finder function for repeats of at least length bases on
+// the same strand. The later copy of each repeat is a match, from its first
+// kmer seen before to its last one.
+func AvoidRepeats(length int) func(string) []Match {
+	return func(sequence string) []Match {
+		sequence = strings.ToUpper(sequence)
+		first := make(map[string]int)
+		var repeated []int
+		for i := 0; i+length <= len(sequence); i++ {
+			kmer := sequence[i : i+length]
+			if _, ok := first[kmer]; ok {
+				repeated = append(repeated, i)
+				continue
+			}
+			first[kmer] = i
+		}
+		return kmerRuns(repeated, length, func(start int, end int) string {
+			return fmt.Sprintf("Repeat of %d bp, %s also at %d", end-start, sequence[start:start+length], first[sequence[start:start+length]])
+		})
+	}
+}
+
+// AvoidHostHomology is a finder function for kmers of length bases shared
+// with a host genome, on either strand. Runs of shared kmers are one match.
+// Circular genomes should be passed through WrapOrigin first.
+func AvoidHostHomology(length int, genome string) func(string) []Match {
+	kmers := make(map[string]bool)
+	genome = strings.ToUpper(genome)
+	for i := 0; i+length <= len(genome); i++ {
+		kmers[genome[i:i+length]] = true
+	}
+	return func(sequence string) []Match {
+		sequence = strings.ToUpper(sequence)
+		var shared []int
+		for i := 0; i+length <= len(sequence); i++ {
+			kmer := sequence[i : i+length]
+			if kmers[kmer] || kmers[transform.ReverseComplement(kmer)] {
+				shared = append(shared, i)
+			}
+		}
+		return kmerRuns(shared, length, func(start int, end int) string {
+			return fmt.Sprintf("Homology with the host genome, %d bp", end-start)
+		})
+	}
+}
+
+// kmerRuns joins the kmers of length bases at the given starts, in order,
+// into one match for every run of overlapping kmers.
+func kmerRuns(starts []int, length int, message func(start int, end int) string) []Match {
+	var matches []Match
+	for i := 0; i < len(starts); {
+		j := i + 1
+		for j < len(starts) && starts[j] == starts[j-1]+1 {
+			j++
+		}
+		start, end := starts[i], starts[j-1]+length
+		matches = append(matches, Match{Start: start, End: end, Message: message(start, end)})
+		i = j
+	}
+	return matches
+}
+
+// findAll returns the start of every, possibly overlapping, occurrence of word.
+func findAll(sequence string, word string) []int {
+	var starts []int
+	if word == "" {
+		return starts
+	}
+	for offset := 0; ; {
+		index := strings.Index(sequence[offset:], word)
+		if index < 0 {
+			return starts
+		}
+		starts = append(starts, offset+index)
+		offset += index + 1
+	}
+}
@@ -0,0 +1,32 @@
+package problems
+
+import (
+	"fmt"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/design"
+)
+
+// AvoidHairpin is a finder function for design.FindHairpins, the hairpins
+// design.FixSequence removes. Each hairpin is a match from the start of its 5'
+// arm to the end of its 3' arm, with the coordinates of both arms in its
+// message.
+func AvoidHairpin(options design.HairpinOptions) (func(string) []Match, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	return func(sequence string) []Match {
+		// The options are valid, so FindHairpins can't fail
+		hairpins, _ := design.FindHairpins(sequence, options)
+		var matches []Match
+		for _, hairpin := range hairpins {
+			matches = append(matches, Match{
+				Start: hairpin.FiveStart,
+				End:   hairpin.ThreeEnd,
+				Message: fmt.Sprintf("Hairpin stem of %d bp, %d mismatches, between %d-%d and %d-%d with a %d bp loop: %s",
+					hairpin.Length(), hairpin.Mismatches, hairpin.FiveStart, hairpin.FiveEnd, hairpin.ThreeStart, hairpin.ThreeEnd,
+					hairpin.Loop(), sequence[hairpin.FiveStart:hairpin.FiveEnd]),
+			})
+		}
+		return matches
+	}, nil
+}
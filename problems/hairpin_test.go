@@ -0,0 +1,37 @@
+package problems
+
+import (
+	"testing"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/design"
+)
+
+func TestAvoidHairpin(t *testing.T) {
+	if _, err := AvoidHairpin(design.HairpinOptions{StemSize: 6, Window: 10, MinLoop: 3}); err == nil {
+		t.Error("AvoidHairpin took a window too short for its stems")
+	}
+	find, err := AvoidHairpin(design.HairpinOptions{StemSize: 6, Window: 20, MinLoop: 3})
+	if err != nil {
+		t.Fatalf("could not make the hairpin finder: %v", err)
+	}
+	tests := []struct {
+		sequence string
+		want     []Match
+	}{
+		{"GGACTCAAAAGAGTCC", []Match{{Start: 0, End: 16, Message: "Hairpin stem of 6 bp, 0 mismatches, between 0-6 and 10-16 with a 4 bp loop: GGACTC"}}},
+		{"CCCCCCCCCCGGACTCAAAAGAGTCC", []Match{{Start: 10, End: 26, Message: "Hairpin stem of 6 bp, 0 mismatches, between 10-16 and 20-26 with a 4 bp loop: GGACTC"}}},
+		{"GGACTCAAAAGAGACC", nil},
+	}
+	for _, test := range tests {
+		matches := find(test.sequence)
+		if len(matches) != len(test.want) {
+			t.Errorf("%s: found %v, want %v", test.sequence, matches, test.want)
+			continue
+		}
+		for i, match := range matches {
+			if match != test.want[i] {
+				t.Errorf("%s: match %d is %+v, want %+v", test.sequence, i, match, test.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package problems
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Open-Science-Global/poly"
+	"github.com/Open-Science-Global/poly/io/fasta"
+	"github.com/Open-Science-Global/poly/io/genbank"
+)
+
+// Part is a sequence read by ReadParts.
+type Part struct {
+	// File is the file the part was read from, and Name names copies of it:
+	// the file name for GenBank files, and the file name with the index of
+	// the record for FASTA files.
+	File     string
+	Name     string
+	Sequence poly.Sequence
+}
+
+// ReadParts reads every GenBank and FASTA file of a directory, or the ones
+// matching a glob. FASTA records become sequences without features, named
+// after their header.
+func ReadParts(input string) ([]Part, error) {
+	pattern := input
+	if info, err := os.Stat(input); err == nil && info.IsDir() {
+		pattern = filepath.Join(input, "*")
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var parts []Part
+	for _, path := range paths {
+		base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".fasta", ".fa", ".fna":
+			for i, record := range fasta.Read(path) {
+				var sequence poly.Sequence
+				sequence.Sequence = record.Sequence
+				sequence.Meta.Name = record.Name
+				sequence.Meta.Locus.Name = record.Name
+				sequence.Meta.Locus.SequenceLength = strconv.Itoa(len(record.Sequence))
+				sequence.Meta.Locus.Linear = true
+				parts = append(parts, Part{path, base + "#" + strconv.Itoa(i), sequence})
+			}
+		case ".gb", ".gbk", ".genbank":
+			sequence := genbank.Read(path)
+			if sequence.Meta.Locus.Name == "" {
+				sequence.Meta.Locus.Name = base
+			}
+			parts = append(parts, Part{path, base, sequence})
+		}
+	}
+	return parts, nil
+}
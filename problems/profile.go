@@ -0,0 +1,182 @@
+package problems
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/design"
+	"github.com/Open-Science-Global/friendzymes_toolkit/goldengate"
+	"github.com/Open-Science-Global/friendzymes_toolkit/rbs"
+	"github.com/Open-Science-Global/poly"
+	"github.com/Open-Science-Global/poly/io/genbank"
+)
+
+// Profile is a named set of rules that parts are checked against, like what
+// our assemblies need or what a synthesis provider accepts.
+type Profile struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Rules       []RuleConfig `json:"rules"`
+	// Genome is the host genome of the host homology rules, and of the
+	// internal-sd rules without an anti Shine-Dalgarno, a GenBank file
+	// relative to the profile.
+	Genome string `json:"genome"`
+}
+
+// RuleConfig describes a rule of a profile. Type picks the finder function:
+//
+//	forbidden      Sequences and the sites of Enzymes, on both strands
+//	repeat         repeats of Length bases in the part
+//	host-homology  Length bases shared with the host genome
+//	hairpin        stems of Stem bases within Window bases, with a loop of at
+//	               least Loop bases and up to Mismatches unpaired bases, see
+//	               design.FindHairpins
+//	internal-sd    start codons after a Shine-Dalgarno pairing with AntiSD, or
+//	               the 16S rRNA of the host genome, under Energy kcal/mol,
+//	               design.DefaultInternalSDEnergy when 0
+//	promoter       sigma A like -35 and -10 boxes on both strands, scoring at
+//	               least Score, see FindPromoters
+//	terminator     hairpins of Stem bases within Window bases, with a loop of
+//	               at least Loop bases, pairing under Energy kcal/mol and
+//	               followed by Length T in the next 8 bases, see FindTerminators
+//
+// Category defaults to the category of the type, restriction site for
+// forbidden sequences.
+type RuleConfig struct {
+	ID         string   `json:"id"`
+	Type       string   `json:"type"`
+	Category   Category `json:"category"`
+	Severity   Severity `json:"severity"`
+	Sequences  []string `json:"sequences"`
+	Enzymes    []string `json:"enzymes"`
+	Length     int      `json:"length"`
+	Stem       int      `json:"stem"`
+	Window     int      `json:"window"`
+	Loop       int      `json:"loop"`
+	Mismatches int      `json:"mismatches"`
+	AntiSD     string   `json:"anti_sd"`
+	Energy     float64  `json:"energy"`
+	Score      int      `json:"score"`
+}
+
+var ruleCategories = map[string]Category{
+	"forbidden":     RestrictionSite,
+	"repeat":        Repeat,
+	"host-homology": HostHomology,
+	"hairpin":       Hairpin,
+	"internal-sd":   InternalStart,
+	"promoter":      CrypticPromoter,
+	"terminator":    IntrinsicTerminator,
+}
+
+// ReadProfile reads a rule profile from a json file.
+func ReadProfile(path string) (Profile, error) {
+	var profile Profile
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return profile, err
+	}
+	if err := json.Unmarshal(file, &profile); err != nil {
+		return profile, fmt.Errorf("could not parse rule profile %s: %v", path, err)
+	}
+	if profile.Genome != "" && !filepath.IsAbs(profile.Genome) {
+		profile.Genome = filepath.Join(filepath.Dir(path), profile.Genome)
+	}
+	return profile, nil
+}
+
+// BuildRules builds the rules of the profile. The host genome is only read
+// when a rule needs it.
+func (profile Profile) BuildRules() ([]Rule, error) {
+	var hostGenome poly.Sequence
+	var rules []Rule
+	for _, config := range profile.Rules {
+		rule := Rule{ID: config.ID, Category: config.Category, Severity: config.Severity}
+		if rule.Category == "" {
+			rule.Category = ruleCategories[config.Type]
+		}
+		switch rule.Severity {
+		case Block, Warn, Info:
+		default:
+			return nil, fmt.Errorf("rule %s of profile %s has unknown severity %q", config.ID, profile.Name, config.Severity)
+		}
+
+		switch config.Type {
+		case "forbidden":
+			sequences := append([]string{}, config.Sequences...)
+			for _, name := range config.Enzymes {
+				enzyme, err := goldengate.GetEnzyme(name)
+				if err != nil {
+					return nil, fmt.Errorf("rule %s of profile %s: %w", config.ID, profile.Name, err)
+				}
+				sequences = append(sequences, enzyme.Site)
+			}
+			rule.Find = AvoidSequences(sequences)
+			for _, sequence := range sequences {
+				if len(sequence) > rule.Span {
+					rule.Span = len(sequence)
+				}
+			}
+		case "repeat":
+			rule.Find = AvoidRepeats(config.Length)
+			rule.Span = config.Length
+		case "host-homology":
+			if profile.Genome == "" {
+				return nil, fmt.Errorf("rule %s of profile %s needs a host genome", config.ID, profile.Name)
+			}
+			if hostGenome.Sequence == "" {
+				hostGenome = genbank.Read(profile.Genome)
+			}
+			rule.Find = AvoidHostHomology(config.Length, WrapOrigin(hostGenome, config.Length))
+			rule.Span = config.Length
+		case "hairpin":
+			var err error
+			if rule.Find, err = AvoidHairpin(design.HairpinOptions{StemSize: config.Stem, Window: config.Window, MinLoop: config.Loop, Mismatches: config.Mismatches}); err != nil {
+				return nil, fmt.Errorf("rule %s of profile %s: %w", config.ID, profile.Name, err)
+			}
+			rule.Span = config.Window
+		case "internal-sd":
+			antiSD := strings.ToUpper(config.AntiSD)
+			if antiSD == "" {
+				if profile.Genome == "" {
+					return nil, fmt.Errorf("rule %s of profile %s needs an anti Shine-Dalgarno or a host genome", config.ID, profile.Name)
+				}
+				if hostGenome.Sequence == "" {
+					hostGenome = genbank.Read(profile.Genome)
+				}
+				var err error
+				if antiSD, err = rbs.AntiSD(hostGenome, rbs.DefaultAntiSDLength); err != nil {
+					return nil, fmt.Errorf("rule %s of profile %s: %w", config.ID, profile.Name, err)
+				}
+			}
+			energy := config.Energy
+			if energy >= 0 {
+				energy = design.DefaultInternalSDEnergy
+			}
+			rule.Find = AvoidInternalSD(antiSD, energy)
+			rule.Span = len(antiSD) + design.InternalSDMaxSpacing + 3
+		case "promoter":
+			promoter := PromoterOptions{MinScore: config.Score}.withDefaults()
+			rule.Find = AvoidPromoter(promoter)
+			rule.Span = len(box35) + promoter.MaxSpacing + len(box10)
+		case "terminator":
+			terminator := TerminatorOptions{
+				HairpinOptions: design.HairpinOptions{StemSize: config.Stem, Window: config.Window, MinLoop: config.Loop, Mismatches: config.Mismatches},
+				Energy:         config.Energy,
+				UTract:         config.Length,
+			}.withDefaults()
+			var err error
+			if rule.Find, err = AvoidTerminator(terminator); err != nil {
+				return nil, fmt.Errorf("rule %s of profile %s: %w", config.ID, profile.Name, err)
+			}
+			rule.Span = terminator.Window + terminator.TailLength
+		default:
+			return nil, fmt.Errorf("rule %s of profile %s has unknown type %q", config.ID, profile.Name, config.Type)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
@@ -0,0 +1,145 @@
+package problems
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Open-Science-Global/poly/transform"
+)
+
+// The consensus boxes of sigma A promoters, the housekeeping promoters of
+// Bacillus subtilis and E. coli (sigma 70), and the TG of extended -10 boxes
+// one base before the -10 box.
+const (
+	box35       = "TTGACA"
+	box10       = "TATAAT"
+	extended10  = "TG"
+	bestSpacing = 17
+)
+
+// PromoterOptions sets which promoters FindPromoters looks for.
+type PromoterOptions struct {
+	// MinScore is the lowest score of a promoter, see Promoter.
+	MinScore int
+	// MinSpacing and MaxSpacing are the bases allowed between the -35 and the
+	// -10 box.
+	MinSpacing int
+	MaxSpacing int
+}
+
+// Defaults of PromoterOptions. A random sequence of 50% GC has a promoter of
+// score 10 about every 10 kb on each strand, and AT rich ones more often.
+const (
+	DefaultPromoterScore      = 10
+	DefaultPromoterMinSpacing = 15
+	DefaultPromoterMaxSpacing = 19
+)
+
+func (options PromoterOptions) withDefaults() PromoterOptions {
+	if options.MinScore <= 0 {
+		options.MinScore = DefaultPromoterScore
+	}
+	if options.MinSpacing <= 0 {
+		options.MinSpacing = DefaultPromoterMinSpacing
+	}
+	if options.MaxSpacing <= 0 {
+		options.MaxSpacing = DefaultPromoterMaxSpacing
+	}
+	return options
+}
+
+// Promoter is a sigma A like promoter, with its -35 box at Box35 and its -10
+// box at Box10, on the Reverse strand or not. Positions are on the given
+// strand even for promoters of the reverse one, and Start and End, not
+// included, cover both boxes. Score is one for each base matching the
+// consensus of the boxes, one for an extended -10 box and minus one for each
+// base the spacing is away from 17, up to 13.
+type Promoter struct {
+	Start   int
+	End     int
+	Box35   string
+	Box10   string
+	Spacing int
+	Score   int
+	Reverse bool
+}
+
+// FindPromoters finds the sigma A like promoters of a sequence on both
+// strands. Promoters of the given strand make sense transcripts, from inside
+// a CDS, and the ones of the reverse strand antisense ones. For each -35 box
+// only the spacing with the best score is kept.
+func FindPromoters(sequence string, options PromoterOptions) []Promoter {
+	options = options.withDefaults()
+	sequence = strings.ToUpper(sequence)
+	promoters := findPromoters(sequence, options, false)
+	reverse := findPromoters(transform.ReverseComplement(sequence), options, true)
+	for _, promoter := range reverse {
+		promoter.Start, promoter.End = len(sequence)-promoter.End, len(sequence)-promoter.Start
+		promoters = append(promoters, promoter)
+	}
+	return promoters
+}
+
+func findPromoters(sequence string, options PromoterOptions, reverse bool) []Promoter {
+	var promoters []Promoter
+	for start := 0; start+len(box35)+options.MinSpacing+len(box10) <= len(sequence); start++ {
+		found := false
+		var best Promoter
+		for spacing := options.MinSpacing; spacing <= options.MaxSpacing; spacing++ {
+			start10 := start + len(box35) + spacing
+			if start10+len(box10) > len(sequence) {
+				break
+			}
+			score := matching(sequence[start:], box35) + matching(sequence[start10:], box10)
+			if sequence[start10-len(extended10)-1:start10-1] == extended10 {
+				score++
+			}
+			if spacing > bestSpacing {
+				score -= spacing - bestSpacing
+			} else {
+				score -= bestSpacing - spacing
+			}
+			if score >= options.MinScore && (!found || score > best.Score) {
+				found = true
+				best = Promoter{Start: start, End: start10 + len(box10), Box35: sequence[start : start+len(box35)], Box10: sequence[start10 : start10+len(box10)], Spacing: spacing, Score: score, Reverse: reverse}
+			}
+		}
+		if found {
+			promoters = append(promoters, best)
+		}
+	}
+	return promoters
+}
+
+// matching counts the first bases of the sequence matching the box.
+func matching(sequence string, box string) int {
+	count := 0
+	for i := 0; i < len(box); i++ {
+		if sequence[i] == box[i] {
+			count++
+		}
+	}
+	return count
+}
+
+// AvoidPromoter is a finder function for FindPromoters. Each promoter is a
+// match from the start of its -35 box to the end of its -10 box, with its
+// strand and score in its message.
+func AvoidPromoter(options PromoterOptions) func(string) []Match {
+	return func(sequence string) []Match {
+		var matches []Match
+		for _, promoter := range FindPromoters(sequence, options) {
+			strand := "sense"
+			if promoter.Reverse {
+				strand = "antisense"
+			}
+			matches = append(matches, Match{
+				Start: promoter.Start,
+				End:   promoter.End,
+				Message: fmt.Sprintf("Cryptic %s promoter, score %d: -35 box %s, %d bp spacer, -10 box %s",
+					strand, promoter.Score, promoter.Box35, promoter.Spacing, promoter.Box10),
+			})
+		}
+		return matches
+	}
+}
@@ -0,0 +1,31 @@
+package problems
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindPromoters(t *testing.T) {
+	tests := []struct {
+		name     string
+		sequence string
+		options  PromoterOptions
+		want     []Promoter
+	}{
+		{"consensus", "TTGACA" + "GCGCGCGCGCGCGCGCG" + "TATAAT", PromoterOptions{},
+			[]Promoter{{Start: 0, End: 29, Box35: "TTGACA", Box10: "TATAAT", Spacing: 17, Score: 12}}},
+		{"extended -10 box", "ttgaca" + "gcgcgcgcgcgcgctgc" + "tataat", PromoterOptions{},
+			[]Promoter{{Start: 0, End: 29, Box35: "TTGACA", Box10: "TATAAT", Spacing: 17, Score: 13}}},
+		{"long spacer", "TTGACA" + "GCGCGCGCGCGCGCGCGCG" + "TATAAT", PromoterOptions{},
+			[]Promoter{{Start: 0, End: 31, Box35: "TTGACA", Box10: "TATAAT", Spacing: 19, Score: 10}}},
+		{"reverse strand", "ATTATA" + "CGCGCGCGCGCGCGCGC" + "TGTCAA", PromoterOptions{},
+			[]Promoter{{Start: 0, End: 29, Box35: "TTGACA", Box10: "TATAAT", Spacing: 17, Score: 12, Reverse: true}}},
+		{"under the score", "TTGACA" + "GCGCGCGCGCGCGCGCG" + "TATAAT", PromoterOptions{MinScore: 13}, nil},
+		{"without a -10 box", "TTGACA" + "GCGCGCGCGCGCGCGCG" + "TATGCC", PromoterOptions{}, nil},
+	}
+	for _, test := range tests {
+		if promoters := FindPromoters(test.sequence, test.options); !reflect.DeepEqual(promoters, test.want) {
+			t.Errorf("%s: found %+v, want %+v", test.name, promoters, test.want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package problems
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Open-Science-Global/poly"
+)
+
+// Category groups rules by the kind of problem they find.
+type Category string
+
+const (
+	RestrictionSite     Category = "restriction site"
+	Homopolymer         Category = "homopolymer"
+	Repeat              Category = "repeat"
+	HostHomology        Category = "host homology"
+	Hairpin             Category = "hairpin"
+	InternalStart       Category = "internal start"
+	CrypticPromoter     Category = "cryptic promoter"
+	IntrinsicTerminator Category = "intrinsic terminator"
+)
+
+// Severity says what a problem means for a part: blocking problems must be
+// fixed before ordering it, warnings should be looked at and info is only for
+// the record.
+type Severity string
+
+const (
+	Block Severity = "block"
+	Warn  Severity = "warn"
+	Info  Severity = "info"
+)
+
+// severityColors are the colors of problem annotations, read by ApE and
+// Benchling from the ApEinfo qualifiers.
+var severityColors = map[Severity]string{
+	Block: "#f44336",
+	Warn:  "#ff9800",
+	Info:  "#2196f3",
+}
+
+// Rule is a finder function with an ID, a category and a severity given to
+// every match it finds.
+type Rule struct {
+	ID       string
+	Category Category
+	Severity Severity
+	Find     func(string) []Match
+	// Span is the most bases a match of the rule can cover, like the length
+	// of the longest forbidden sequence or the hairpin window. Circular
+	// sequences are searched that far across their origin, and not at all
+	// when it is 0.
+	Span int
+}
+
+// Problem is a match with the rule that found it. Problems found across the
+// origin of a circular sequence end past the end of the sequence.
+type Problem struct {
+	Match
+	Rule     string
+	Category Category
+	Severity Severity
+}
+
+// Find runs every rule on the sequence and returns their problems sorted by
+// position.
+func Find(sequence string, rules []Rule) []Problem {
+	var problems []Problem
+	for _, rule := range rules {
+		for _, match := range rule.Find(sequence) {
+			problems = append(problems, Problem{Match: match, Rule: rule.ID, Category: rule.Category, Severity: rule.Severity})
+		}
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Start < problems[j].Start
+	})
+	return problems
+}
+
+// FindCircular runs every rule on a circular sequence. Each rule also searches
+// the first Span-1 bases of the sequence again after its end, so matches
+// across the origin are found, and matches that start there are left out as
+// they were already found at the start. Sequences shorter than the span are
+// wrapped by all but their last base, so they are never searched next to a
+// whole copy of themselves.
+func FindCircular(sequence string, rules []Rule) []Problem {
+	var problems []Problem
+	for _, rule := range rules {
+		wrapped := sequence
+		if rule.Span > 1 && len(sequence) > 0 {
+			wrap := rule.Span - 1
+			if wrap > len(sequence)-1 {
+				wrap = len(sequence) - 1
+			}
+			wrapped += sequence[:wrap]
+		}
+		for _, problem := range Find(wrapped, []Rule{rule}) {
+			if problem.Start < len(sequence) {
+				problems = append(problems, problem)
+			}
+		}
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Start < problems[j].Start
+	})
+	return problems
+}
+
+// FindSequence runs every rule on a poly.Sequence, across its origin when its
+// locus is circular.
+func FindSequence(sequence poly.Sequence, rules []Rule) []Problem {
+	if sequence.Meta.Locus.Circular {
+		return FindCircular(strings.ToUpper(sequence.Sequence), rules)
+	}
+	return Find(strings.ToUpper(sequence.Sequence), rules)
+}
+
+// WrapOrigin returns the sequence in upper case followed, when it is circular,
+// by its first length-1 bases, so searches for up to length bases, like the
+// host genome kmers of GlobalRemoveRepeat, also find the ones across its
+// origin.
+func WrapOrigin(sequence poly.Sequence, length int) string {
+	wrapped := strings.ToUpper(sequence.Sequence)
+	if sequence.Meta.Locus.Circular && length > 1 && length-1 <= len(wrapped) {
+		wrapped += wrapped[:length-1]
+	}
+	return wrapped
+}
+
+// Blocking returns the problems with Block severity.
+func Blocking(problems []Problem) []Problem {
+	var blocking []Problem
+	for _, problem := range problems {
+		if problem.Severity == Block {
+			blocking = append(blocking, problem)
+		}
+	}
+	return blocking
+}
+
+// Annotate adds a feature for each problem to the sequence, with its rule,
+// category and severity as qualifiers and colored by severity. Problems across
+// the origin are joined from their start to the end of the sequence and from
+// its first base to their end.
+func Annotate(problems []Problem, sequence poly.Sequence) poly.Sequence {
+	for _, problem := range problems {
+		feature := poly.Feature{
+			Type:             "misc_feature",
+			Description:      problem.Message,
+			SequenceLocation: location(problem, len(sequence.Sequence)),
+			Attributes: map[string]string{
+				"label":            problem.Rule,
+				"note":             problem.Message,
+				"category":         string(problem.Category),
+				"severity":         string(problem.Severity),
+				"ApEinfo_fwdcolor": severityColors[problem.Severity],
+				"ApEinfo_revcolor": severityColors[problem.Severity],
+			},
+		}
+		sequence.AddFeature(&feature)
+	}
+	return sequence
+}
+
+// location is where a problem is in a sequence of the given length.
+func location(problem Problem, length int) poly.Location {
+	if problem.End <= length {
+		return poly.Location{Start: problem.Start, End: problem.End}
+	}
+	return poly.Location{
+		Start: problem.Start,
+		End:   problem.End - length,
+		Join:  true,
+		SubLocations: []poly.Location{
+			{Start: problem.Start, End: length},
+			{Start: 0, End: problem.End - length},
+		},
+	}
+}
@@ -0,0 +1,35 @@
+package problems
+
+import (
+	"testing"
+)
+
+func TestFindCircular(t *testing.T) {
+	rules := []Rule{
+		{ID: "repeats", Category: Repeat, Severity: Warn, Find: AvoidRepeats(10), Span: 10},
+		{ID: "restriction-sites", Category: RestrictionSite, Severity: Block, Find: AvoidSequences([]string{"GGTCTC"}), Span: 6},
+	}
+	tests := []struct {
+		name     string
+		sequence string
+		want     []Problem
+	}{
+		{"shorter than the span", "ACGTTGCA", nil},
+		{"as long as the span", "ACGTTGCAAG", nil},
+		{"site across the origin", "TCTCAAACCCTTTGGG", []Problem{{Match: Match{Start: 14, End: 20}, Rule: "restriction-sites"}}},
+		{"repeat of a short sequence", "ACGACGACG", []Problem{{Match: Match{Start: 3, End: 17}, Rule: "repeats"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := FindCircular(test.sequence, rules)
+			if len(got) != len(test.want) {
+				t.Fatalf("FindCircular(%s) found %v, want %v", test.sequence, got, test.want)
+			}
+			for i, problem := range got {
+				if problem.Start != test.want[i].Start || problem.End != test.want[i].End || problem.Rule != test.want[i].Rule {
+					t.Errorf("FindCircular(%s) problem %d = %+v, want %+v", test.sequence, i, problem, test.want[i])
+				}
+			}
+		})
+	}
+}
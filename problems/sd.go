@@ -0,0 +1,28 @@
+package problems
+
+import (
+	"fmt"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/design"
+)
+
+// AvoidInternalSD is a finder function for design.InternalStarts: start
+// codons, after the first codon of the sequence, with a Shine-Dalgarno like
+// sequence pairing with the anti Shine-Dalgarno under energy kcal/mol before
+// them. Each is a match from the start of the Shine-Dalgarno to the end of the
+// start codon. Only the given strand is searched, as only it is translated.
+// The RBS of a part with its UTR is found too, so the rule is meant for CDSs.
+func AvoidInternalSD(antiSD string, energy float64) func(string) []Match {
+	return func(sequence string) []Match {
+		var matches []Match
+		for _, start := range design.InternalStarts(sequence, antiSD, energy) {
+			matches = append(matches, Match{
+				Start: start.SDStart,
+				End:   start.Start + 3,
+				Message: fmt.Sprintf("Internal start %s at %d, in frame %d, after a Shine-Dalgarno %s at %d-%d pairing with %.2f kcal/mol",
+					start.Codon, start.Start, start.Start%3, sequence[start.SDStart:start.SDEnd], start.SDStart, start.SDEnd, start.Energy),
+			})
+		}
+		return matches
+	}
+}
@@ -0,0 +1,142 @@
+package problems
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/design"
+)
+
+// TerminatorOptions sets which intrinsic terminators FindTerminators looks
+// for: hairpins of HairpinOptions, whose stem pairs under Energy kcal/mol,
+// followed by at least UTract T in the TailLength bases after them.
+type TerminatorOptions struct {
+	design.HairpinOptions
+	Energy     float64
+	UTract     int
+	TailLength int
+}
+
+// Defaults of TerminatorOptions, for the short GC rich stems of bacterial
+// terminators.
+const (
+	DefaultTerminatorStem       = 6
+	DefaultTerminatorWindow     = 30
+	DefaultTerminatorLoop       = 3
+	DefaultTerminatorEnergy     = -9.0
+	DefaultTerminatorUTract     = 5
+	DefaultTerminatorTailLength = 8
+)
+
+func (options TerminatorOptions) withDefaults() TerminatorOptions {
+	if options.StemSize <= 0 {
+		options.StemSize = DefaultTerminatorStem
+	}
+	if options.Window <= 0 {
+		options.Window = DefaultTerminatorWindow
+	}
+	if options.MinLoop <= 0 {
+		options.MinLoop = DefaultTerminatorLoop
+	}
+	if options.Energy >= 0 {
+		options.Energy = DefaultTerminatorEnergy
+	}
+	if options.UTract <= 0 {
+		options.UTract = DefaultTerminatorUTract
+	}
+	if options.TailLength <= 0 {
+		options.TailLength = DefaultTerminatorTailLength
+	}
+	return options
+}
+
+// Terminator is a rho independent terminator like hairpin, with the free
+// energy of its stem in kcal/mol and the number of T in its tail.
+type Terminator struct {
+	design.HairpinStem
+	Energy float64
+	UTract int
+	Tail   string
+}
+
+// End is the end of the tail of the terminator, not included.
+func (terminator Terminator) End() int {
+	return terminator.ThreeEnd + len(terminator.Tail)
+}
+
+// FindTerminators finds the intrinsic terminators of a sequence, on the given
+// strand only, as they only stop the transcription of that strand. The T
+// ending the 3' arm of a hairpin are counted in its tail rather than its stem.
+func FindTerminators(sequence string, options TerminatorOptions) ([]Terminator, error) {
+	options = options.withDefaults()
+	sequence = strings.ToUpper(sequence)
+	hairpins, err := design.FindHairpins(sequence, options.HairpinOptions)
+	if err != nil {
+		return nil, err
+	}
+	var terminators []Terminator
+	for _, hairpin := range hairpins {
+		// The U-tract often pairs with As before the stem, it starts at the first T ending the 3' arm
+		for hairpin.Length() > 2 && sequence[hairpin.ThreeEnd-1] == 'T' {
+			hairpin.FiveStart++
+			hairpin.ThreeEnd--
+		}
+		hairpin.Mismatches = hairpin.CountMismatches(sequence)
+		tailEnd := hairpin.ThreeEnd + options.TailLength
+		if tailEnd > len(sequence) {
+			tailEnd = len(sequence)
+		}
+		tail := sequence[hairpin.ThreeEnd:tailEnd]
+		uTract := strings.Count(tail, "T")
+		if uTract < options.UTract {
+			continue
+		}
+		if energy := stemEnergy(sequence, hairpin); energy <= options.Energy {
+			terminators = append(terminators, Terminator{HairpinStem: hairpin, Energy: energy, UTract: uTract, Tail: tail})
+		}
+	}
+	return terminators, nil
+}
+
+// stemEnergy is the free energy of the stem of a hairpin, each run of pairs
+// between mismatches counted as its own helix.
+func stemEnergy(sequence string, hairpin design.HairpinStem) float64 {
+	energy := 0.0
+	runStart := -1
+	for i := 0; i <= hairpin.Length(); i++ {
+		paired := i < hairpin.Length() && design.Pairs(sequence[hairpin.FiveStart+i], sequence[hairpin.ThreeEnd-1-i])
+		if paired && runStart < 0 {
+			runStart = i
+		}
+		if !paired && runStart >= 0 {
+			if i-runStart >= 2 {
+				energy += design.HelixEnergy(sequence[hairpin.FiveStart+runStart : hairpin.FiveStart+i])
+			}
+			runStart = -1
+		}
+	}
+	return energy
+}
+
+// AvoidTerminator is a finder function for FindTerminators. Each terminator
+// is a match from the start of its 5' arm to the end of its tail, with the
+// energy of its stem in its message.
+func AvoidTerminator(options TerminatorOptions) (func(string) []Match, error) {
+	if err := options.withDefaults().Validate(); err != nil {
+		return nil, err
+	}
+	return func(sequence string) []Match {
+		// The options are valid, so FindTerminators can't fail
+		terminators, _ := FindTerminators(sequence, options)
+		var matches []Match
+		for _, terminator := range terminators {
+			matches = append(matches, Match{
+				Start: terminator.FiveStart,
+				End:   terminator.End(),
+				Message: fmt.Sprintf("Intrinsic terminator, stem of %d bp pairing with %.2f kcal/mol, %d mismatches, %d bp loop, tail %s with %d T",
+					terminator.Length(), terminator.Energy, terminator.Mismatches, terminator.Loop(), terminator.Tail, terminator.UTract),
+			})
+		}
+		return matches
+	}, nil
+}
@@ -0,0 +1,29 @@
+package problems
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/design"
+)
+
+func TestStemEnergy(t *testing.T) {
+	stem := design.HairpinStem{FiveStart: 0, FiveEnd: 6, ThreeStart: 10, ThreeEnd: 16}
+	tests := []struct {
+		name     string
+		sequence string
+		want     float64
+	}{
+		// 4.09 to start the helix, then GG GA AC CT TC
+		{"paired", "GGACTCAAAAGAGTCC", 4.09 - 3.26 - 2.35 - 2.24 - 2.08 - 2.35},
+		// A mismatch splits the stem in a GG and a CTC helix
+		{"mismatch", "GGACTCAAAAGAGACC", 4.09 - 3.26 + 4.09 - 2.08 - 2.35},
+		// Single pairs between mismatches make no helix, only AC is left
+		{"single pairs", "GGACTCAAAAGTGTGC", 4.09 - 2.24},
+	}
+	for _, test := range tests {
+		if energy := stemEnergy(test.sequence, stem); math.Abs(energy-test.want) > 1e-9 {
+			t.Errorf("%s: stem energy %.2f, want %.2f", test.name, energy, test.want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Package rbs designs and evaluates 5' UTRs for a CDS in a host. A UTR is
+// scored by how well its Shine-Dalgarno sequence pairs with the anti
+// Shine-Dalgarno at the 3' end of the host 16S rRNA, how far it is from the
+// start codon and how much the UTR folds with the start of the CDS. The score
+// ranks UTRs against each other, it doesn't predict translation rates.
+package rbs
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/design"
+	"github.com/Open-Science-Global/poly"
+	"github.com/Open-Science-Global/poly/transform"
+)
+
+// Defaults of Options.
+const (
+	DefaultAntiSDLength = 13
+	DefaultTries        = 4
+	// Spacings from the end of the Shine-Dalgarno to the start codon outside
+	// MinSpacing and MaxSpacing are penalized by SpacingPenalty for each base.
+	MinSpacing     = 5
+	MaxSpacing     = 9
+	SpacingPenalty = 1.0
+)
+
+// AntiSD is the last bases of the first 16S rRNA of a genome, as DNA, 5' to
+// 3'. The 16S rRNA is an rRNA feature whose product, gene or note says 16S.
+func AntiSD(genome poly.Sequence, length int) (string, error) {
+	for _, feature := range genome.Features {
+		if feature.Type != "rRNA" {
+			continue
+		}
+		attributes := feature.Attributes["product"] + " " + feature.Attributes["gene"] + " " + feature.Attributes["note"]
+		if !strings.Contains(attributes, "16S") && !strings.Contains(attributes, "rrs") {
+			continue
+		}
+		rRNA := strings.ToUpper(feature.GetSequence())
+		if len(rRNA) < length {
+			return "", errors.New("16S rRNA shorter than the anti Shine-Dalgarno")
+		}
+		return rRNA[len(rRNA)-length:], nil
+	}
+	return "", errors.New("no 16S rRNA feature in the genome")
+}
+
+// Options sets how UTRs are scored and designed.
+type Options struct {
+	// AntiSD is the 3' end of the host 16S rRNA, see AntiSD.
+	AntiSD string
+	// Upstream starts every designed UTR, like the end of the promoter or the
+	// overhang of the RBS part.
+	Upstream string
+	// Codons is how many codons of the CDS are folded with the UTR,
+	// design.DefaultStartFoldCodons when 0.
+	Codons int
+	// Tries is how many random spacers are tried for each Shine-Dalgarno and
+	// spacing, DefaultTries when 0.
+	Tries int
+	// Rand picks the spacers, the shared math/rand source is used when it is
+	// nil.
+	Rand *rand.Rand
+}
+
+func (options Options) tries() int {
+	if options.Tries <= 0 {
+		return DefaultTries
+	}
+	return options.Tries
+}
+
+// UTR is a scored 5' UTR.
+type UTR struct {
+	Sequence string `json:"sequence"`
+	// SD is the part of the UTR pairing best with the anti Shine-Dalgarno,
+	// starting at SDStart, and SDEnergy the free energy of the pairs in
+	// kcal/mol, 0 when nothing pairs.
+	SD       string  `json:"sd"`
+	SDStart  int     `json:"sd_start"`
+	SDEnergy float64 `json:"sd_energy"`
+	// Spacing is the number of bases between the SD and the start codon.
+	Spacing int `json:"spacing"`
+	// Fold is the design.FoldScore of the UTR with the first codons of the
+	// CDS, a CONTRAfold score rather than a free energy.
+	Fold float64 `json:"fold"`
+	// Score adds the SD energy, the spacing penalty and the fold score. They
+	// are not in the same units, so it only ranks UTRs. Lower is better.
+	Score float64 `json:"score"`
+}
+
+// Evaluate scores a UTR for a CDS.
+func Evaluate(utr string, cds string, options Options) UTR {
+	utr = strings.ToUpper(utr)
+	scored := UTR{Sequence: utr, Spacing: len(utr)}
+	start, end, energy := design.PairSD(utr, options.AntiSD)
+	if end > start {
+		scored.SD, scored.SDStart, scored.SDEnergy, scored.Spacing = utr[start:end], start, energy, len(utr)-end
+	}
+	scored.Fold = design.StartFold{UTR: utr, Codons: options.Codons}.Score(strings.ToUpper(cds))
+
+	scored.Score = scored.SDEnergy + scored.Fold
+	switch {
+	case scored.Spacing < MinSpacing:
+		scored.Score += SpacingPenalty * float64(MinSpacing-scored.Spacing)
+	case scored.Spacing > MaxSpacing:
+		scored.Score += SpacingPenalty * float64(scored.Spacing-MaxSpacing)
+	}
+	return scored
+}
+
+// Design makes UTRs for a CDS and returns the best ones, best first. Each UTR
+// is the upstream bases, a Shine-Dalgarno complementary to 6 to 9 bases of
+// the anti Shine-Dalgarno, and an AT rich spacer of MinSpacing to MaxSpacing
+// bases without start codons.
+func Design(cds string, options Options, count int) ([]UTR, error) {
+	antiSD := strings.ToUpper(options.AntiSD)
+	if antiSD == "" {
+		return nil, errors.New("no anti Shine-Dalgarno to design UTRs for")
+	}
+	intn := rand.Intn
+	if options.Rand != nil {
+		intn = options.Rand.Intn
+	}
+
+	target := transform.ReverseComplement(antiSD)
+	found := make(map[string]bool)
+	var utrs []UTR
+	for length := 6; length <= 9 && length <= len(target); length++ {
+		for start := 0; start+length <= len(target); start++ {
+			sd := target[start : start+length]
+			for spacing := MinSpacing; spacing <= MaxSpacing; spacing++ {
+				for try := 0; try < options.tries(); try++ {
+					utr := strings.ToUpper(options.Upstream) + sd + spacer(sd, spacing, intn)
+					if found[utr] {
+						continue
+					}
+					found[utr] = true
+					utrs = append(utrs, Evaluate(utr, cds, options))
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(utrs, func(i, j int) bool {
+		return utrs[i].Score < utrs[j].Score
+	})
+	if count > 0 && count < len(utrs) {
+		utrs = utrs[:count]
+	}
+	return utrs, nil
+}
+
+// spacer makes an AT rich spacer after an SD, three A or T for each C or G,
+// without an ATG, GTG or TTG that could start translation early.
+func spacer(sd string, length int, intn func(int) int) string {
+	const bases = "AAATTTCG"
+	for {
+		var sequence strings.Builder
+		for i := 0; i < length; i++ {
+			sequence.WriteByte(bases[intn(len(bases))])
+		}
+		spacer := sequence.String()
+		if !strings.Contains(sd[len(sd)-1:]+spacer, "TG") {
+			return spacer
+		}
+	}
+}
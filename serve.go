@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/design"
+	"github.com/Open-Science-Global/friendzymes_toolkit/host"
+	"github.com/Open-Science-Global/friendzymes_toolkit/server"
+)
+
+// serve runs the HTTP API of the server package, designing with the strategies of a host, whose codon tables
+// are made by a normal run. A server serves one host, picked with -host.
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	address := flags.String("addr", "127.0.0.1:8080", "address to listen on")
+	directory := flags.String("jobs", "data/jobs", "directory the jobs are saved in")
+	workers := flags.Int("workers", 1, "number of jobs run at the same time")
+	timeout := flags.Duration("timeout", 0, "longest time each job may take, e.g. 10m, no limit when 0")
+	hostName := flags.String("host", host.Default, "host profile in data/hosts, or the path of a host directory")
+	alternativeORFs := flags.Int("alternative-orfs", 0, "shorten alternative ORFs of at least this many codons when fixing designs, none when 0")
+	_ = flags.Parse(args)
+
+	hostProfile, err := host.Read(host.Directory, *hostName)
+	if err != nil {
+		fmt.Println("Could not read the host profile:", err)
+		os.Exit(1)
+	}
+	// The codon tables of the strategies are made by a run without serve, see host.BuildCodonTables
+	codonTables, err := hostProfile.CodonTables()
+	if err != nil {
+		fmt.Println("Could not read the codon tables of the host, run without serve first to make them:", err)
+		os.Exit(1)
+	}
+	if hostProfile.Genome == "" {
+		fmt.Println("Host", hostProfile.Name, "has no genome, designs are not checked for homology with it.")
+	}
+	strategies := make(map[string]server.Strategy)
+	unsupported := make(map[string]string)
+	for _, strategy := range hostProfile.Strategies {
+		if strategy.Harmonize {
+			unsupported[strategy.Name] = "jobs only have a protein, there is no native CDS to harmonize"
+			continue
+		}
+		ramp, err := strategy.DesignRamp()
+		if err != nil {
+			fmt.Println("Could not read the ramp of", strategy.Name+":", err)
+			os.Exit(1)
+		}
+		startFold, err := hostProfile.DesignStartFold(strategy)
+		if err != nil {
+			fmt.Println("Could not read the start fold of", strategy.Name+":", err)
+			os.Exit(1)
+		}
+		strategies[strategy.Name] = server.Strategy{Name: strategy.Name, Description: strategy.Description, CodonTable: codonTables[strategy.Name], Ramp: ramp, StartFold: startFold}
+	}
+
+	fmt.Println("Creating a Kmer Table from Host Genome...")
+	hostOptions, err := hostProfile.DesignOptions()
+	if err != nil {
+		fmt.Println("Could not read the host genome:", err)
+		os.Exit(1)
+	}
+	hostOptions.AlternativeORFCodons = *alternativeORFs
+
+	// Problems are reported with the rules of the host, like lint.go and scan.go do
+	rules, err := hostProfile.BuildRules()
+	if err != nil {
+		fmt.Println("Could not make the rules of the host:", err)
+		os.Exit(1)
+	}
+
+	lockedRegions, err := design.ReadLockedRegions("data/locked-regions.json")
+	if err != nil {
+		fmt.Println("Could not read locked regions:", err)
+		os.Exit(1)
+	}
+
+	jobServer, err := server.New(server.Config{
+		Directory:     *directory,
+		Strategies:    strategies,
+		Unsupported:   unsupported,
+		Options:       hostOptions,
+		Rules:         rules,
+		LockedRegions: lockedRegions,
+		Workers:       *workers,
+		Timeout:       *timeout,
+	})
+	if err != nil {
+		fmt.Println("Could not open the jobs:", err)
+		os.Exit(1)
+	}
+
+	// Jobs left running on Ctrl-C run again on the next start
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	jobServer.Start(ctx)
+
+	httpServer := &http.Server{Addr: *address, Handler: jobServer}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Println("Serving on http://" + *address)
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		fmt.Println("Could not serve:", err)
+		os.Exit(1)
+	}
+}
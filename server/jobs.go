@@ -0,0 +1,189 @@
+// Package server runs design and problem-finding jobs behind a local HTTP/JSON
+// API, for people who would rather not run Go programs. Jobs are saved to
+// disk as they go, so a restarted server picks up the ones it didn't finish.
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/design"
+	"github.com/Open-Science-Global/friendzymes_toolkit/problems"
+)
+
+// Job types.
+const (
+	// Design codon optimizes a protein and fixes the problems of its CDS.
+	Design = "design"
+	// Check finds the problems of a CDS.
+	Check = "check"
+)
+
+// Job statuses.
+const (
+	Queued  = "queued"
+	Running = "running"
+	Done    = "done"
+	Failed  = "failed"
+)
+
+// Request is what is submitted to run a job.
+type Request struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	// Protein is designed by design jobs, Sequence is the CDS checked by
+	// check jobs.
+	Protein  string `json:"protein"`
+	Sequence string `json:"sequence"`
+	// Strategy names the codon table to design with.
+	Strategy string `json:"strategy"`
+	// Seed picks the codons of design jobs, a new one is picked and saved
+	// with the job when it is 0.
+	Seed int64 `json:"seed"`
+}
+
+// Job is a submitted request and, once it is done, what it made.
+type Job struct {
+	ID       string    `json:"id"`
+	Status   string    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+	Request  Request   `json:"request"`
+	Report   *Report   `json:"report,omitempty"`
+	Attempts int       `json:"attempts"`
+}
+
+// Report is the result of a job.
+type Report struct {
+	Name     string `json:"name"`
+	Strategy string `json:"strategy,omitempty"`
+	Seed     int64  `json:"seed,omitempty"`
+	// Optimized is the CDS before its problems were fixed and Sequence the
+	// designed or checked CDS. Problems are the problems the rules of the
+	// server find in Sequence, with their rule and severity.
+	Optimized      string            `json:"optimized,omitempty"`
+	Sequence       string            `json:"sequence"`
+	Problems       []problems.Record `json:"problems"`
+	LockedProblems []string          `json:"locked_problems,omitempty"`
+	Files          map[string]string `json:"files"`
+	// Ramp scores the 5' codon ramp of designs with one.
+	Ramp *design.RampScore `json:"ramp,omitempty"`
+	// ORFs are the long alternative ORFs and out of frame stops of the CDS.
+	ORFs design.ORFAnalysis `json:"orfs"`
+}
+
+// store keeps every job in its own directory, as job.json next to the files
+// it made.
+type store struct {
+	directory string
+	mutex     sync.Mutex
+	jobs      map[string]*Job
+}
+
+// openStore reads the jobs saved in a directory.
+func openStore(directory string) (*store, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, err
+	}
+	jobStore := &store{directory: directory, jobs: make(map[string]*Job)}
+	paths, err := filepath.Glob(filepath.Join(directory, "*", "job.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		file, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var job Job
+		if err := json.Unmarshal(file, &job); err != nil {
+			return nil, fmt.Errorf("could not read job %s: %v", path, err)
+		}
+		jobStore.jobs[job.ID] = &job
+	}
+	return jobStore, nil
+}
+
+// add saves a new queued job for the request.
+func (jobStore *store) add(request Request) (Job, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Job{}, err
+	}
+	now := time.Now().UTC()
+	job := Job{ID: hex.EncodeToString(id), Status: Queued, Created: now, Updated: now, Request: request}
+	if err := os.MkdirAll(jobStore.path(job.ID), 0755); err != nil {
+		return Job{}, err
+	}
+
+	jobStore.mutex.Lock()
+	defer jobStore.mutex.Unlock()
+	jobStore.jobs[job.ID] = &job
+	return job, jobStore.save(job)
+}
+
+// update changes a job and saves it.
+func (jobStore *store) update(id string, change func(job *Job)) (Job, error) {
+	jobStore.mutex.Lock()
+	defer jobStore.mutex.Unlock()
+	job, ok := jobStore.jobs[id]
+	if !ok {
+		return Job{}, errors.New("no job " + id)
+	}
+	change(job)
+	job.Updated = time.Now().UTC()
+	return *job, jobStore.save(*job)
+}
+
+func (jobStore *store) get(id string) (Job, bool) {
+	jobStore.mutex.Lock()
+	defer jobStore.mutex.Unlock()
+	job, ok := jobStore.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// list returns every job, oldest first.
+func (jobStore *store) list() []Job {
+	jobStore.mutex.Lock()
+	defer jobStore.mutex.Unlock()
+	jobs := []Job{}
+	for _, job := range jobStore.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Created.Before(jobs[j].Created)
+	})
+	return jobs
+}
+
+// path is the directory of a job.
+func (jobStore *store) path(id string) string {
+	return filepath.Join(jobStore.directory, id)
+}
+
+// save writes job.json through a temporary file, so a job is never left half
+// written.
+func (jobStore *store) save(job Job) error {
+	file, err := json.MarshalIndent(job, "", " ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(jobStore.path(job.ID), "job.json")
+	if err := ioutil.WriteFile(path+".tmp", file, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
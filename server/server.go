@@ -0,0 +1,388 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/design"
+	"github.com/Open-Science-Global/friendzymes_toolkit/problems"
+	"github.com/Open-Science-Global/poly"
+	"github.com/Open-Science-Global/poly/io/fasta"
+	"github.com/Open-Science-Global/poly/io/genbank"
+	"github.com/Open-Science-Global/poly/transform/codon"
+)
+
+// Strategy is a codon table that proteins can be designed with.
+type Strategy struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	CodonTable  codon.Table `json:"-"`
+	// Ramp, when set, starts the designs with a 5' codon ramp.
+	Ramp *design.Ramp `json:"ramp,omitempty"`
+	// StartFold, when set, unfolds the 5' end of the mRNA of the designs.
+	StartFold *design.StartFold `json:"start_fold,omitempty"`
+}
+
+// Config sets up a Server for one host.
+type Config struct {
+	// Directory is where jobs are saved.
+	Directory  string
+	Strategies map[string]Strategy
+	// Unsupported are strategies of the host that jobs can't use, with why,
+	// like harmonization strategies, as jobs have no native CDS.
+	Unsupported map[string]string
+	// Options are the design options of every job, like the host kmers.
+	// Each design job gets its own Rand seeded with its seed.
+	Options design.Options
+	// Rules are the rules every CDS is checked with, like the rule profile
+	// of the host.
+	Rules []problems.Rule
+	// LockedRegions are the locked regions of each protein, by name.
+	LockedRegions map[string][]design.LockedRegion
+	// Workers is how many jobs run at the same time, 1 when it is 0.
+	Workers int
+	// Timeout fails any job that takes longer, no timeout when it is 0.
+	Timeout time.Duration
+}
+
+// Server runs jobs and serves the API:
+//
+//	GET  /strategies               the strategies jobs can use
+//	POST /jobs                     submit a Request, returns the Job
+//	GET  /jobs                     every job
+//	GET  /jobs/{id}                a job and its status
+//	GET  /jobs/{id}/fasta          the CDS of a finished job
+//	GET  /jobs/{id}/genbank        the CDS with its problems as features
+//	GET  /jobs/{id}/report         the Report of a finished job
+//
+// Every job is designed for the host of the config: its genome, codon tables,
+// forbidden sequences and rules. Requests can't pick another host, so a
+// server is started for each host jobs are made for.
+type Server struct {
+	config  Config
+	store   *store
+	pending chan string
+}
+
+// New opens the jobs saved in the directory of the config.
+func New(config Config) (*Server, error) {
+	jobStore, err := openStore(config.Directory)
+	if err != nil {
+		return nil, err
+	}
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	return &Server{config: config, store: jobStore, pending: make(chan string)}, nil
+}
+
+// MaxAttempts is how many times a job is started before it fails. Jobs
+// stopped with the server are started again on the next start, so a job that
+// stops the server every time it runs doesn't run forever.
+const MaxAttempts = 3
+
+// Start runs the jobs until the context is done, beginning with the ones left
+// queued or running when the server last stopped. Jobs stopped by the context
+// are left running, so they run again on the next start, up to MaxAttempts
+// times.
+func (server *Server) Start(ctx context.Context) {
+	queue := make(chan string)
+	go dispatch(ctx, server.pending, queue)
+	for worker := 0; worker < server.config.Workers; worker++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-queue:
+					server.run(ctx, id)
+				}
+			}
+		}()
+	}
+
+	for _, job := range server.store.list() {
+		if job.Status == Queued || job.Status == Running {
+			server.enqueue(ctx, job.ID)
+		}
+	}
+}
+
+// dispatch passes ids from in to out in the order they came, holding as many
+// as needed so submitting a job never waits for a worker.
+func dispatch(ctx context.Context, in chan string, out chan string) {
+	var pending []string
+	for {
+		var next chan string
+		var id string
+		if len(pending) > 0 {
+			next, id = out, pending[0]
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case newID := <-in:
+			pending = append(pending, newID)
+		case next <- id:
+			pending = pending[1:]
+		}
+	}
+}
+
+func (server *Server) enqueue(ctx context.Context, id string) {
+	select {
+	case <-ctx.Done():
+	case server.pending <- id:
+	}
+}
+
+// run runs a job and saves what it made. The job holds the worker until it
+// stops, design jobs stop soon after the context is done.
+func (server *Server) run(ctx context.Context, id string) {
+	job, err := server.store.update(id, func(job *Job) {
+		if job.Attempts >= MaxAttempts {
+			job.Status = Failed
+			job.Error = fmt.Sprintf("stopped %d times before finishing", job.Attempts)
+			return
+		}
+		job.Status = Running
+		job.Attempts++
+	})
+	if err != nil || job.Status == Failed {
+		return
+	}
+
+	jobCtx := ctx
+	if server.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		jobCtx, cancel = context.WithTimeout(ctx, server.config.Timeout)
+		defer cancel()
+	}
+
+	report, err := server.runJob(jobCtx, job)
+	if ctx.Err() != nil {
+		// The server is stopping, the job runs again when it starts
+		return
+	}
+	_, _ = server.store.update(id, func(job *Job) {
+		if err != nil {
+			job.Status = Failed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = Done
+		job.Error = ""
+		job.Report = &report
+	})
+}
+
+func (server *Server) runJob(ctx context.Context, job Job) (Report, error) {
+	request := job.Request
+	options := server.config.Options
+	options.LockedRegions = server.config.LockedRegions[request.Name]
+	report := Report{Name: request.Name, Strategy: request.Strategy}
+
+	switch request.Type {
+	case Design:
+		strategy := server.config.Strategies[request.Strategy]
+		options.Rand = rand.New(rand.NewSource(request.Seed))
+		options.Ramp = strategy.Ramp
+		options.StartFold = strategy.StartFold
+		result, err := design.Design(ctx, request.Protein, strategy.CodonTable, options)
+		if err != nil {
+			return Report{}, err
+		}
+		report.Seed = request.Seed
+		report.Optimized = result.Optimized
+		report.Sequence = result.Sequence
+		report.LockedProblems = result.LockedProblems
+		if strategy.Ramp != nil {
+			score := strategy.Ramp.Score(result.Sequence, strategy.CodonTable)
+			report.Ramp = &score
+		}
+	case Check:
+		report.Sequence = request.Sequence
+	}
+	found := problems.Find(report.Sequence, server.config.Rules)
+	report.Problems = problems.Records(found)
+	report.ORFs = design.AnalyzeORFs(report.Sequence, options.AlternativeORFCodons)
+	return report, server.writeFiles(job.ID, &report, found)
+}
+
+// writeFiles writes the FASTA, GenBank and report of a finished job.
+func (server *Server) writeFiles(id string, report *Report, found []problems.Problem) error {
+	directory := server.store.path(id)
+	report.Files = map[string]string{
+		"fasta":   "/jobs/" + id + "/fasta",
+		"genbank": "/jobs/" + id + "/genbank",
+		"report":  "/jobs/" + id + "/report",
+	}
+
+	fasta.Write([]fasta.Fasta{{Name: report.Name, Sequence: report.Sequence}}, filepath.Join(directory, "cds.fasta"))
+	genbank.Write(annotate(*report, found), filepath.Join(directory, "cds.gb"))
+
+	file, err := json.MarshalIndent(report, "", " ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(directory, "report.json"), file, 0644)
+}
+
+// annotate makes a GenBank sequence of the CDS of a report, with its problems
+// as misc_features, see problems.Annotate.
+func annotate(report Report, found []problems.Problem) poly.Sequence {
+	var sequence poly.Sequence
+	sequence.Sequence = report.Sequence
+	sequence.Meta.Name = report.Name
+	sequence.Meta.Locus.Name = strings.Join(strings.Fields(report.Name), "_")
+	sequence.Meta.Locus.SequenceLength = strconv.Itoa(len(report.Sequence))
+	sequence.Meta.Locus.MoleculeType = "DNA"
+	sequence.Meta.Locus.Linear = true
+
+	cds := poly.Feature{
+		Type:             "CDS",
+		SequenceLocation: poly.Location{Start: 0, End: len(report.Sequence)},
+		Attributes:       map[string]string{"label": report.Name},
+	}
+	sequence.AddFeature(&cds)
+	return problems.Annotate(found, sequence)
+}
+
+// ServeHTTP serves the API.
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	switch {
+	case path == "strategies" && r.Method == http.MethodGet:
+		server.listStrategies(w)
+	case path == "jobs" && r.Method == http.MethodPost:
+		server.submit(w, r)
+	case path == "jobs" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, server.store.list())
+	case len(parts) == 2 && parts[0] == "jobs" && r.Method == http.MethodGet:
+		job, ok := server.store.get(parts[1])
+		if !ok {
+			writeError(w, http.StatusNotFound, "no job "+parts[1])
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	case len(parts) == 3 && parts[0] == "jobs" && r.Method == http.MethodGet:
+		server.download(w, r, parts[1], parts[2])
+	default:
+		writeError(w, http.StatusNotFound, "no endpoint "+r.Method+" /"+path)
+	}
+}
+
+func (server *Server) listStrategies(w http.ResponseWriter) {
+	var strategies []Strategy
+	for _, strategy := range server.config.Strategies {
+		strategies = append(strategies, strategy)
+	}
+	sort.Slice(strategies, func(i, j int) bool {
+		return strategies[i].Name < strategies[j].Name
+	})
+	writeJSON(w, http.StatusOK, strategies)
+}
+
+func (server *Server) submit(w http.ResponseWriter, r *http.Request) {
+	var request Request
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "could not read request: "+err.Error())
+		return
+	}
+	if err := server.validate(&request); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	job, err := server.store.add(request)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "could not save job: "+err.Error())
+		return
+	}
+	server.enqueue(r.Context(), job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// validate checks a request and fills in its defaults, so the saved job runs
+// the same way after a restart.
+func (server *Server) validate(request *Request) error {
+	if request.Type == "" {
+		request.Type = Design
+	}
+	if request.Name == "" {
+		request.Name = "sequence"
+	}
+	switch request.Type {
+	case Design:
+		request.Protein = strings.ToUpper(strings.TrimSpace(request.Protein))
+		if request.Protein == "" {
+			return errors.New("design jobs need a protein")
+		}
+		if reason, ok := server.config.Unsupported[request.Strategy]; ok {
+			return fmt.Errorf("strategy %q can't be used by jobs: %s", request.Strategy, reason)
+		}
+		if _, ok := server.config.Strategies[request.Strategy]; !ok {
+			return fmt.Errorf("unknown strategy %q, see /strategies", request.Strategy)
+		}
+		if request.Seed == 0 {
+			request.Seed = time.Now().UnixNano()
+		}
+	case Check:
+		request.Sequence = strings.ToUpper(strings.TrimSpace(request.Sequence))
+		if request.Sequence == "" {
+			return errors.New("check jobs need a sequence")
+		}
+	default:
+		return fmt.Errorf("unknown job type %q, use %s or %s", request.Type, Design, Check)
+	}
+	return nil
+}
+
+var downloads = map[string]struct {
+	file        string
+	contentType string
+}{
+	"fasta":   {"cds.fasta", "text/plain; charset=utf-8"},
+	"genbank": {"cds.gb", "text/plain; charset=utf-8"},
+	"report":  {"report.json", "application/json"},
+}
+
+func (server *Server) download(w http.ResponseWriter, r *http.Request, id string, name string) {
+	download, ok := downloads[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "no file "+name+", use fasta, genbank or report")
+		return
+	}
+	job, ok := server.store.get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "no job "+id)
+		return
+	}
+	if job.Status != Done {
+		writeError(w, http.StatusConflict, "job "+id+" is "+job.Status)
+		return
+	}
+	w.Header().Set("Content-Type", download.contentType)
+	http.ServeFile(w, r, filepath.Join(server.store.path(id), download.file))
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
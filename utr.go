@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Open-Science-Global/friendzymes_toolkit/host"
+	"github.com/Open-Science-Global/friendzymes_toolkit/manifest"
+	"github.com/Open-Science-Global/friendzymes_toolkit/rbs"
+	"github.com/Open-Science-Global/poly/io/fasta"
+)
+
+// utrReport is the UTRs of a CDS: the UTR of the host scored for reference and the designed variants, best first.
+type utrReport struct {
+	Name     string    `json:"name"`
+	HostUTR  *rbs.UTR  `json:"host_utr,omitempty"`
+	Variants []rbs.UTR `json:"variants"`
+}
+
+// designUTRs designs and ranks 5' UTRs for each CDS of a FASTA file, like the designs of a normal run.
+func designUTRs(args []string) {
+	flags := flag.NewFlagSet("utr", flag.ExitOnError)
+	input := flags.String("input", "data/output/output.fasta", "FASTA file of the CDSs to design UTRs for")
+	output := flags.String("output", "data/output/utr.json", "json file the ranked UTRs are written to")
+	hostName := flags.String("host", host.Default, "host profile in data/hosts, or the path of a host directory")
+	upstream := flags.String("upstream", "", "bases every UTR starts with, like the end of the promoter or a part overhang")
+	count := flags.Int("count", 5, "number of UTRs kept for each CDS")
+	seed := flags.Int64("seed", 0, "seed for the spacers, a new one is picked when 0")
+	_ = flags.Parse(args)
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	fmt.Println("Seed:", *seed)
+
+	hostProfile, err := host.Read(host.Directory, *hostName)
+	if err != nil {
+		fmt.Println("Could not read the host profile:", err)
+		os.Exit(1)
+	}
+	antiSD, err := hostProfile.ReadAntiSD()
+	if err != nil {
+		fmt.Println("Could not find the anti Shine-Dalgarno of the host:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Anti Shine-Dalgarno of %s: %s\n\n", hostProfile.Name, antiSD)
+
+	// fasta.Read doesn't return its errors
+	if _, err := os.Stat(*input); err != nil {
+		fmt.Println("Could not read the CDSs:", err)
+		os.Exit(1)
+	}
+	options := rbs.Options{AntiSD: antiSD, Upstream: *upstream, Rand: rand.New(rand.NewSource(*seed))}
+	var reports []utrReport
+	for _, cds := range fasta.Read(*input) {
+		report := utrReport{Name: cds.Name}
+		if hostProfile.UTR != "" {
+			hostUTR := rbs.Evaluate(hostProfile.UTR, cds.Sequence, options)
+			report.HostUTR = &hostUTR
+		}
+		report.Variants, err = rbs.Design(cds.Sequence, options, *count)
+		if err != nil {
+			fmt.Println("Could not design UTRs for", cds.Name+":", err)
+			os.Exit(1)
+		}
+		reports = append(reports, report)
+
+		fmt.Println(cds.Name)
+		table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "rank\tutr\tsd\tspacing\tsd energy\tfold\tscore")
+		if report.HostUTR != nil {
+			printUTR(table, "host", *report.HostUTR)
+		}
+		for i, utr := range report.Variants {
+			printUTR(table, fmt.Sprint(i+1), utr)
+		}
+		table.Flush()
+		fmt.Printf("\n")
+	}
+
+	file, _ := json.MarshalIndent(reports, "", " ")
+	if err := ioutil.WriteFile(*output, file, 0644); err != nil {
+		fmt.Println("Could not write the UTRs:", err)
+		os.Exit(1)
+	}
+
+	// Spacers are random, the manifest has what is needed to make the same UTRs again
+	runManifest := manifest.New("utr", *seed)
+	flags.VisitAll(func(f *flag.Flag) {
+		runManifest.SetParameter(f.Name, f.Value.String())
+	})
+	addManifestInput(runManifest, filepath.Join(hostProfile.Directory, "host.json"))
+	if hostProfile.AntiSD == "" {
+		addManifestInput(runManifest, hostProfile.Genome)
+	}
+	addManifestInput(runManifest, *input)
+	addManifestOutput(runManifest, *output)
+	manifestPath := strings.TrimSuffix(*output, filepath.Ext(*output)) + ".manifest.json"
+	if err := runManifest.Write(manifestPath); err != nil {
+		fmt.Println("Could not write the run manifest:", err)
+		os.Exit(1)
+	}
+	fmt.Println("UTRs written to", *output, "and their manifest to", manifestPath)
+}
+
+func printUTR(table *tabwriter.Writer, rank string, utr rbs.UTR) {
+	fmt.Fprintf(table, "%s\t%s\t%s\t%d\t%.2f\t%.2f\t%.2f\n", rank, utr.Sequence, utr.SD, utr.Spacing, utr.SDEnergy, utr.Fold, utr.Score)
+}